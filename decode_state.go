@@ -5,15 +5,122 @@
 package form
 
 import (
+	"context"
+	"database/sql"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"net"
+	"net/url"
+	"os"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var (
-	errInvalidValue = errors.New("form: invalid value")
+	errInvalidValue  = errors.New("form: invalid value")
+	errOverflow      = errors.New("form: value out of range")
+	errInvalidBool   = errors.New("form: invalid boolean")
+	errInvalidNumber = errors.New("form: invalid number")
+	errInvalidIP     = errors.New("form: invalid IP address")
+	errInvalidChar   = errors.New("form: invalid character")
 )
 
+// rawFieldName is the form key of a map field that receives every
+// form value rather than the "key[name]" subset.
+const rawFieldName = "_raw"
+
+// noField stands for the field of values that are not loaded into
+// a struct field, such as map entries. It must not be modified.
+var noField field
+
+// envDefaultPrefix starts a default tag value naming an environment
+// variable, as in `default:"$ENV:PAGE_SIZE|20"`.
+const envDefaultPrefix = "$ENV:"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	numberType          = reflect.TypeOf(json.Number(""))
+	stringsType         = reflect.TypeOf([]string(nil))
+	bigIntType          = reflect.TypeOf(big.Int{})
+	bigFloatType        = reflect.TypeOf(big.Float{})
+	ipType              = reflect.TypeOf(net.IP{})
+	ipNetType           = reflect.TypeOf(net.IPNet{})
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType     = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// isTextUnmarshaler reports whether a value of type t is loaded
+// through its encoding.TextUnmarshaler implementation.
+func isTextUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// isBytes reports whether t is a byte slice, loaded from a single
+// encoded value rather than element by element.
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// isSequence reports whether t is a slice or an array loaded
+// element by element from the values of a key.
+func isSequence(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array || t == ipType {
+		return false
+	}
+	return !isTextUnmarshaler(t) && !isBytes(t)
+}
+
+// indirect returns the element type of t if t is a pointer, or t itself.
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// isNestedStruct reports whether a field of type t is loaded field by field
+// from prefixed keys rather than from a single value.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType || t == ipNetType || t == urlType {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !isTextUnmarshaler(t) && !pt.Implements(unmarshalerType) && !pt.Implements(scannerType)
+}
+
+// formUnmarshaler returns the Unmarshaler implemented by v or its address.
+// For a nil pointer v, it is implemented by a new value instead, also
+// returned, that v is to be set to once it loads. It returns nil if v is
+// not an Unmarshaler.
+func formUnmarshaler(v reflect.Value) (Unmarshaler, reflect.Value) {
+	if v.Kind() != reflect.Pointer {
+		v = v.Addr()
+	}
+	if !v.Type().Implements(unmarshalerType) {
+		return nil, reflect.Value{}
+	}
+	if v.IsNil() {
+		p := reflect.New(v.Type().Elem())
+		return p.Interface().(Unmarshaler), p
+	}
+	return v.Interface().(Unmarshaler), reflect.Value{}
+}
+
 type InvalidLoadError struct {
 	Type reflect.Type
 }
@@ -29,6 +136,16 @@ func (e *InvalidLoadError) Error() string {
 	return "form: Parse(nil " + e.Type.String() + ")"
 }
 
+// An InvalidTargetError describes a pointer passed to Load
+// that does not point to a struct or a map.
+type InvalidTargetError struct {
+	Type reflect.Type // type of the pointer
+}
+
+func (e *InvalidTargetError) Error() string {
+	return "form: target must point to a struct, got " + e.Type.String()
+}
+
 // An LoadTypeError describes a form value that was
 // not appropriate for a value of a specific Go type.
 type LoadTypeError struct {
@@ -36,18 +153,150 @@ type LoadTypeError struct {
 	Type   reflect.Type // type of Go value it could not be assigned to
 	Struct string       // name of the struct type containing the field
 	Field  string       // the full path from root node to the field
+	Msg    string       // message of the msg= tag option of the field, if any
 }
 
 func (e *LoadTypeError) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
 	if e.Struct != "" || e.Field != "" {
 		return "form: cannot load " + e.Value + " into Go struct field " + e.Struct + "." + e.Field + " of type " + e.Type.String()
 	}
 	return "form: cannot load " + e.Value + " into Go value of type " + e.Type.String()
 }
 
+// A MissingFieldError describes a required field
+// whose key is absent from the form values.
+type MissingFieldError struct {
+	Field string // the form key of the field
+	Msg   string // message of the msg= tag option of the field, if any
+}
+
+func (e *MissingFieldError) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return "form: missing required field " + e.Field
+}
+
+// LoadErrors holds every error found while loading form values
+// when the Decoder is set to collect errors.
+type LoadErrors []error
+
+func (e LoadErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the collected errors, so errors.Is and errors.As
+// match any of them.
+func (e LoadErrors) Unwrap() []error {
+	return e
+}
+
+// ToMap returns a message for each field path, suitable for reporting
+// the errors to a client, for example as a JSON object. Errors not tied
+// to a field are keyed by the empty string. Only the first error of
+// each field is kept.
+func (e LoadErrors) ToMap() map[string]string {
+	m := make(map[string]string, len(e))
+	for _, err := range e {
+		field, msg := errorField(err)
+		if _, ok := m[field]; !ok {
+			m[field] = msg
+		}
+	}
+	return m
+}
+
+// errorField returns the field path of err and a message describing it.
+func errorField(err error) (field, msg string) {
+	var (
+		typeErr    *LoadTypeError
+		missingErr *MissingFieldError
+		dupErr     *DuplicateValueError
+		unknownErr *UnknownFieldError
+		lenErr     *SliceLenError
+	)
+	switch {
+	case errors.As(err, &typeErr):
+		if typeErr.Msg != "" {
+			return typeErr.Field, typeErr.Msg
+		}
+		return typeErr.Field, "invalid " + typeErr.Value
+	case errors.As(err, &missingErr):
+		if missingErr.Msg != "" {
+			return missingErr.Field, missingErr.Msg
+		}
+		return missingErr.Field, "required"
+	case errors.As(err, &dupErr):
+		return dupErr.Field, "duplicate value"
+	case errors.As(err, &unknownErr):
+		return unknownErr.Key, "unknown field"
+	case errors.As(err, &lenErr):
+		return lenErr.Field, "too many values"
+	}
+	return "", err.Error()
+}
+
+// A DuplicateValueError describes a non-slice field that received
+// several values while the Decoder rejects duplicates.
+type DuplicateValueError struct {
+	Field string // the full path from root node to the field
+}
+
+func (e *DuplicateValueError) Error() string {
+	return "form: duplicate values for field " + e.Field
+}
+
+// A SliceLenError describes a slice field that received more elements
+// than the maximum set with Decoder.SetMaxSliceLen.
+type SliceLenError struct {
+	Field string // the full path from root node to the field
+	Max   int    // the maximum number of elements
+}
+
+func (e *SliceLenError) Error() string {
+	return "form: too many values for field " + e.Field + " (max " + strconv.Itoa(e.Max) + ")"
+}
+
+// An UnknownFieldError describes a form key that does not match
+// any field of the target struct in strict mode, or that looks like
+// a typo of a field key when the Decoder suggests unknown keys.
+type UnknownFieldError struct {
+	Key        string // the unmatched form key
+	Suggestion string // a field key close to Key, if requested
+}
+
+func (e *UnknownFieldError) Error() string {
+	if e.Suggestion != "" {
+		return "form: unknown field " + e.Key + " (did you mean " + e.Suggestion + "?)"
+	}
+	return "form: unknown field " + e.Key
+}
+
 type decodeState struct {
+	ctx        context.Context
+	dec        *Decoder
 	data       map[string][]string
+	raw        map[string][]string // data as passed in, before normalization
 	savedError error
+	errs       LoadErrors
+	usedKeys   map[string]bool
+	knownKeys  []string     // field keys looked up, for suggestions
+	matched    int          // number of d.data keys matched so far
+	fieldStack []string     // path segments of the value being loaded
+	structType reflect.Type // struct type of the field being loaded
+	msg        string       // message of the errors of the field being loaded
+	errCount   int          // number of errors saved so far
+	populated  int          // number of fields loaded from d.data
+
+	// files holds the file parts of a multipart form.
+	files map[string][]*multipart.FileHeader
 }
 
 func (d *decodeState) parse(v any) error {
@@ -60,119 +309,1618 @@ func (d *decodeState) parse(v any) error {
 		return d.addErrorContext(err)
 	}
 
+	if err := d.result(); err != nil {
+		return err
+	}
+
+	if val, ok := v.(Validator); ok {
+		return val.Validate()
+	}
+	return nil
+}
+
+// parseValue loads raw into the value v, which must be settable.
+func (d *decodeState) parseValue(raw []string, v reflect.Value) error {
+	if !v.IsValid() {
+		return &InvalidLoadError{}
+	}
+	if !v.CanSet() {
+		return &InvalidLoadError{v.Type()}
+	}
+
+	d.store(d.normalizeValues(raw), v, &field{})
+
+	return d.result()
+}
+
+// result returns the errors saved while loading.
+func (d *decodeState) result() error {
+	if len(d.errs) > 0 {
+		return d.errs
+	}
+
 	return d.savedError
 }
 
 func (d *decodeState) value(rv reflect.Value) error {
 	v := rv.Elem()
+	switch v.Kind() {
+	case reflect.Struct:
+	case reflect.Map:
+		d.formMap(v)
+		return nil
+	default:
+		return &InvalidTargetError{rv.Type()}
+	}
+
+	d.object(v, "")
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+
+	if d.dec.strict || d.dec.suggestUnknown {
+		for _, k := range sortedKeys(d.data) {
+			if d.usedKeys[k] {
+				continue
+			}
+			suggestion := d.suggestKey(k)
+			if d.dec.strict || suggestion != "" {
+				d.saveError(&UnknownFieldError{Key: k, Suggestion: suggestion})
+			}
+		}
+	}
+
+	return nil
+}
+
+// object loads the fields of the struct v. Form keys of the fields
+// are looked up with prefix prepended.
+func (d *decodeState) object(v reflect.Value, prefix string) {
 	t := v.Type()
-	if t.Kind() != reflect.Struct {
-		return errInvalidValue
+
+	// Errors in an inline struct type, which has no name, are reported
+	// against the enclosing named struct, its path being in the field.
+	outer := d.structType
+	if t.Name() != "" || outer == nil {
+		d.structType = t
 	}
+	defer func() { d.structType = outer }()
+
+	fields := cachedFields(t, d.dec.tagName)
+	for i := range fields {
+		if d.ctx.Err() != nil {
+			return
+		}
 
-	fieldAliasNames := make([]string, t.NumField())
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+		f := &fields[i]
+		fieldValue, embedded := allocFieldByIndex(v, f.index)
 
-		aliasName := field.Tag.Get("request")
-		if aliasName != "" {
-			fieldAliasNames[i] = aliasName
-			continue
+		matched := d.matched
+		d.field(fieldValue, f, prefix, t)
+		if embedded.IsValid() && d.matched == matched {
+			embedded.Set(reflect.Zero(embedded.Type()))
+		}
+	}
+}
+
+// field loads the struct field f, whose value is v, of the struct type t.
+func (d *decodeState) field(v reflect.Value, f *field, prefix string, t reflect.Type) {
+	name := f.name
+	if !f.named && d.dec.nameMapper != nil {
+		name = d.dec.nameMapper(name)
+	}
+	key := prefix + name
+	d.pushField(name)
+	defer d.popField()
+	defer func(msg string) { d.msg = msg }(d.msg)
+	d.msg = f.msg
+	defer d.recoverField(len(d.fieldStack), v.Type())
+
+	switch ft := v.Type(); {
+	case ft == fileHeaderType || ft == fileHeadersType:
+		d.fileField(v, f, key)
+		return
+	case d.isNestedStruct(ft):
+		if !d.nullKey(key) {
+			d.object(v, key+d.dec.nestedSeparator)
+		}
+		return
+	case ft.Kind() == reflect.Pointer && d.isNestedStruct(ft.Elem()) && !d.hasConverter(ft):
+		if !d.nullKey(key) {
+			d.structPointer(v, key+d.dec.nestedSeparator)
+		}
+		return
+	case ft.Kind() == reflect.Map && name == rawFieldName:
+		errCount := d.errCount
+		d.formMap(v)
+		d.countField(len(d.data) > 0, errCount)
+		return
+	case ft.Kind() == reflect.Interface && !d.hasConverter(ft):
+		if impl, ok := d.dec.interfaceImpl(ft); ok {
+			d.interfaceField(v, f, impl, prefix, key)
+			return
+		}
+	case ft.Kind() == reflect.Map && !d.hasConverter(ft) && !f.options.Contains("kv"):
+		if !d.nullKey(key) {
+			errCount := d.errCount
+			d.countField(d.mapValues(v, key), errCount)
+		}
+		return
+	case ft.Kind() == reflect.Slice && d.isNestedStruct(indirect(ft.Elem())) && !d.hasConverter(ft):
+		if !d.nullKey(key) {
+			d.structSlice(v, key)
 		}
+		return
+	}
+
+	if d.dec.suggestUnknown {
+		d.knownKeys = append(d.knownKeys, d.normalizeKey(key))
+		for _, alias := range f.aliases {
+			d.knownKeys = append(d.knownKeys, d.normalizeKey(prefix+alias))
+		}
+	}
 
-		fieldAliasNames[i] = field.Name
+	dataV, ok := d.lookup(key)
+	for _, alias := range f.aliases {
+		if ok {
+			break
+		}
+		if dataV, ok = d.lookup(prefix + alias); ok {
+			key = prefix + alias
+			d.fieldStack[len(d.fieldStack)-1] = alias
+		}
+	}
+	if d.dec.arrayBrackets && isSequence(indirect(v.Type())) {
+		if more, found := d.lookup(key + "[]"); found {
+			dataV = append(dataV[:len(dataV):len(dataV)], more...)
+			ok = true
+		}
 	}
+	if ok && f.options.Contains("presence") && indirect(v.Type()).Kind() == reflect.Bool && (len(dataV) == 0 || dataV[0] == "") {
+		// A bare key such as "?verbose" sets a presence flag.
+		dataV = []string{"true"}
+	}
+	if ok && d.dec.skipEmpty && len(dataV) > 0 && dataV[0] == "" && !isSequence(v.Type()) {
+		ok = false
+	}
+	if !ok {
+		if f.required {
+			d.saveError(&MissingFieldError{})
+			return
+		}
 
-	for i, fieldAliasName := range fieldAliasNames {
-		dataV, ok := d.data[fieldAliasName]
+		if !f.hasDefault {
+			return
+		}
+		def, ok := defaultValue(f.defaultValue)
 		if !ok {
-			continue
+			return
 		}
+		dataV = []string{def}
+	}
 
-		fieldValue := v.Field(i)
+	errCount := d.errCount
+	stored := d.store(dataV, v, f)
+	d.countField(ok && stored, errCount)
+}
 
-		if !fieldValue.CanSet() {
-			continue
+// interfaceField loads the interface field f, whose value is v, into
+// a new value of the type impl registers for the discriminator value
+// found next to the field key. v is set only when the new value loads
+// without errors.
+func (d *decodeState) interfaceField(v reflect.Value, f *field, impl interfaceImpl, prefix, key string) {
+	disc, ok := d.lookup(prefix + impl.key)
+	if !ok || len(disc) == 0 || d.isNull(disc[0]) {
+		if f.required {
+			d.saveError(&MissingFieldError{})
+		}
+		return
+	}
+
+	t, ok := impl.types[disc[0]]
+	if !ok || !t.Implements(v.Type()) {
+		d.saveError(&LoadTypeError{Value: "discriminator " + disc[0], Type: v.Type()})
+		return
+	}
+
+	errCount := d.errCount
+	elem := reflect.New(t).Elem()
+	if target := indirect(t); d.isNestedStruct(target) {
+		if t.Kind() == reflect.Pointer {
+			elem.Set(reflect.New(target))
+		}
+		d.object(reflect.Indirect(elem), key+d.dec.nestedSeparator)
+		if d.errCount == errCount {
+			v.Set(elem)
+		}
+		return
+	}
+
+	dataV, ok := d.lookup(key)
+	if !ok {
+		return
+	}
+	stored := d.store(dataV, elem, f)
+	if d.errCount == errCount && stored {
+		v.Set(elem)
+	}
+	d.countField(stored, errCount)
+}
+
+// fileField loads the file parts of key into v, a *multipart.FileHeader
+// receiving the first part or a []*multipart.FileHeader receiving all
+// of them. v is left unchanged when there are none.
+func (d *decodeState) fileField(v reflect.Value, f *field, key string) {
+	files := d.files[key]
+	if len(files) == 0 {
+		if f.required {
+			d.saveError(&MissingFieldError{})
 		}
+		return
+	}
+
+	if v.Type() == fileHeaderType {
+		v.Set(reflect.ValueOf(files[0]))
+	} else {
+		v.Set(reflect.ValueOf(append([]*multipart.FileHeader(nil), files...)))
+	}
+	d.countField(true, d.errCount)
+}
+
+// defaultValue returns the value of the default tag def. A value of the
+// form "$ENV:NAME" is read from the environment variable NAME when the
+// field is loaded; if it is unset, the literal after a "|", as in
+// "$ENV:NAME|20", is used instead, and without one no default applies.
+func defaultValue(def string) (string, bool) {
+	name, ok := strings.CutPrefix(def, envDefaultPrefix)
+	if !ok {
+		return def, true
+	}
+
+	name, fallback, hasFallback := strings.Cut(name, "|")
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	return fallback, hasFallback
+}
+
+// duplicate reports whether dataV holds several values for a non-slice
+// field while duplicates are rejected, saving a DuplicateValueError.
+func (d *decodeState) duplicate(dataV []string) bool {
+	if !d.dec.rejectDups || len(dataV) < 2 {
+		return false
+	}
+	d.saveError(&DuplicateValueError{Field: d.fieldPath()})
+	return true
+}
+
+// recoverField converts a panic while loading a field of type t into
+// a LoadTypeError for the field, so that the other fields are still
+// loaded. depth is the length of the field stack with the field pushed.
+func (d *decodeState) recoverField(depth int, t reflect.Type) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	d.fieldStack = d.fieldStack[:depth]
+	d.saveError(&LoadTypeError{Value: fmt.Sprintf("value (panic: %v)", r), Type: t})
+}
 
-		if fieldValue.Kind() == reflect.Slice {
-			if fieldValue.Len() == 0 {
-				v.Set(reflect.MakeSlice(v.Type(), len(dataV), len(dataV)))
-			}
-
-			for i := 0; i < fieldValue.Len(); i++ {
-				fieldValueI := fieldValue.Index(i)
-				switch fieldValue.Type().Elem().Kind() {
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					intV, _ := strconv.ParseInt(dataV[i], 10, 64)
-					fieldValueI.SetInt(intV)
-				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-					intV, err := strconv.ParseUint(dataV[i], 10, 64)
-					if err != nil {
-						d.saveError(&LoadTypeError{Value: "array " + dataV[i], Type: v.Type()})
-					}
-					fieldValueI.SetUint(intV)
-				case reflect.Float32, reflect.Float64:
-					n, err := strconv.ParseFloat(dataV[i], fieldValueI.Type().Bits())
-					if err != nil || fieldValueI.OverflowFloat(n) {
-						d.saveError(&LoadTypeError{Value: "array " + dataV[i], Type: v.Type()})
-						break
-					}
-					fieldValueI.SetFloat(n)
-				case reflect.String, reflect.Interface:
-					fieldValueI.SetString(dataV[i])
+// countField counts a field as populated if it was loaded from d.data
+// without saving errors since errCount were saved.
+func (d *decodeState) countField(loaded bool, errCount int) {
+	if loaded && d.errCount == errCount {
+		d.populated++
+	}
+}
+
+// allocFieldByIndex returns the nested field of v at index, allocating
+// nil embedded struct pointers on the way. embedded is the outermost
+// pointer it allocated, or the zero Value if it allocated none.
+func allocFieldByIndex(v reflect.Value, index []int) (field, embedded reflect.Value) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+				if !embedded.IsValid() {
+					embedded = v
 				}
 			}
+			v = v.Elem()
 		}
+		v = v.Field(x)
+	}
+	return v, embedded
+}
 
-		if len(dataV) < 1 {
-			continue
+// store loads dataV, the form values of a key, into v, the value of field f.
+// It reports whether dataV held a value to load, as opposed to none
+// or the null value; errors loading it are saved.
+func (d *decodeState) store(dataV []string, v reflect.Value, f *field) bool {
+	if d.hasConverter(v.Type()) {
+		if len(dataV) == 0 || d.isNull(dataV[0]) {
+			return false
+		}
+		if d.duplicate(dataV) {
+			return true
 		}
+		if err := d.literalStore(dataV[0], v, f); err != nil {
+			d.literalError(v, literalKind(v.Type()), dataV[0], err)
+		}
+		return true
+	}
 
-		if dataV[0] == "null" {
-			continue
+	if u, p := formUnmarshaler(v); u != nil {
+		if err := u.UnmarshalForm(dataV); err != nil {
+			d.saveError(err)
+			return true
+		}
+		if p.IsValid() {
+			v.Set(p)
 		}
+		return true
+	}
 
-		switch fieldValue.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			intV, _ := strconv.ParseInt(dataV[0], 10, 64)
-			fieldValue.SetInt(intV)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-			intV, err := strconv.ParseUint(dataV[0], 10, 64)
-			if err != nil {
-				d.saveError(&LoadTypeError{Value: "number " + dataV[0], Type: v.Type()})
+	if v.Kind() == reflect.Map && f.options.Contains("kv") {
+		if len(dataV) == 0 || len(dataV) == 1 && d.isNull(dataV[0]) {
+			return false
+		}
+		d.keyValues(v, dataV)
+		return true
+	}
+
+	// The elements are loaded into a copy of the sequence, and a nil pointer
+	// to one is only set, once they all load, so that a field failing to
+	// load keeps its prior value.
+	var ptr reflect.Value
+	if v.Kind() == reflect.Pointer && isSequence(v.Type().Elem()) {
+		if len(dataV) == 1 && d.isNull(dataV[0]) {
+			return false
+		}
+		ptr = v
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem()).Elem()
+		} else {
+			v = v.Elem()
+		}
+	}
+
+	if isSequence(v.Type()) {
+		if len(dataV) == 1 && d.isNull(dataV[0]) {
+			return false
+		}
+
+		if d.dec.sliceDelimiter != "" && len(dataV) == 1 {
+			dataV = splitValue(dataV[0], d.dec.sliceDelimiter)
+		}
+
+		errCount := d.errCount
+		seq := reflect.New(v.Type()).Elem()
+		offset := 0
+		if v.Kind() == reflect.Slice {
+			if d.dec.sliceAppend {
+				offset = v.Len()
+			}
+			n := offset + len(dataV)
+			if d.sliceTooLong(n, d.dec.maxSliceLen) {
+				return true
+			}
+			seq.Set(reflect.MakeSlice(v.Type(), n, n))
+			reflect.Copy(seq, v.Slice(0, offset))
+		} else {
+			seq.Set(v)
+		}
+
+		if v.Kind() == reflect.Array && len(dataV) > v.Len() && d.dec.strict {
+			d.saveError(&LoadTypeError{Value: "array " + dataV[v.Len()], Type: v.Type()})
+		}
+
+		for i := 0; offset+i < seq.Len() && i < len(dataV); i++ {
+			elem := seq.Index(offset + i)
+			if elem.Kind() == reflect.Pointer && d.isNull(dataV[i]) {
+				elem.Set(reflect.Zero(elem.Type()))
+				continue
+			}
+			d.pushIndex(offset + i)
+			if err := d.literalStore(dataV[i], elem, f); err != nil {
+				d.literalError(elem, "array", dataV[i], err)
 			}
-			fieldValue.SetUint(intV)
-		case reflect.Bool:
-			v.SetBool(dataV[0] == "true" || dataV[0] == "1")
-		case reflect.Float32, reflect.Float64:
-			n, err := strconv.ParseFloat(dataV[0], fieldValue.Type().Bits())
-			if err != nil || fieldValue.OverflowFloat(n) {
-				d.saveError(&LoadTypeError{Value: "number " + dataV[0], Type: v.Type()})
-				break
+			d.popField()
+		}
+
+		if d.errCount == errCount {
+			v.Set(seq)
+			if ptr.IsValid() && ptr.IsNil() {
+				ptr.Set(v.Addr())
 			}
-			fieldValue.SetFloat(n)
-		case reflect.String, reflect.Interface:
-			fieldValue.SetString(dataV[0])
-		default:
-			d.savedError = errInvalidValue
 		}
+		return true
 	}
 
-	return nil
+	if len(dataV) < 1 || d.isNull(dataV[0]) {
+		return false
+	}
+
+	if v.Kind() != reflect.Interface && d.duplicate(dataV) {
+		return true
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.NumMethod() != 0 {
+			d.saveError(&LoadTypeError{Value: "string " + dataV[0], Type: v.Type()})
+			return true
+		}
+
+		if len(dataV) == 1 {
+			v.Set(reflect.ValueOf(dataV[0]))
+			return true
+		}
+		v.Set(reflect.ValueOf(append([]string(nil), dataV...)))
+		return true
+	}
+
+	// A nil pointer is only set once its value loads, so that a field
+	// failing to load keeps its prior value.
+	target := v
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			target = reflect.New(v.Type().Elem()).Elem()
+		} else {
+			target = v.Elem()
+		}
+	}
+
+	if err := d.literalStore(dataV[0], target, f); err != nil {
+		d.literalError(target, literalKind(target.Type()), dataV[0], err)
+		return true
+	}
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		v.Set(target.Addr())
+	}
+	return true
 }
 
-func (d *decodeState) saveError(err error) {
-	if d.savedError == nil {
-		d.savedError = d.addErrorContext(err)
+// formMap copies every form key into the map v, as passed to the Decoder
+// rather than normalized by its key and space settings. Values are kept as is
+// for a []string element type, joined with the slice delimiter (a comma
+// by default) for a string element type, and converted from the first
+// value otherwise. Every key counts as matched, so a struct with a "_raw"
+// field has no unknown keys in strict mode.
+func (d *decodeState) formMap(v reflect.Value) {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+
+	sep := d.dec.sliceDelimiter
+	if sep == "" {
+		sep = ","
+	}
+
+	for dataKey := range d.data {
+		d.markUsed(dataKey)
+	}
+	for _, rawKey := range sortedKeys(d.raw) {
+		d.pushField(rawKey)
+		d.formMapEntry(v, rawKey, d.raw[rawKey], sep)
+		d.popField()
 	}
 }
 
-func (d *decodeState) init(data map[string][]string) {
-	d.savedError = nil
-	d.data = data
+// formMapEntry stores dataV, the form values of dataKey, into the map v.
+func (d *decodeState) formMapEntry(v reflect.Value, dataKey string, dataV []string, sep string) {
+	t := v.Type()
+	mapKey := reflect.New(t.Key()).Elem()
+	if err := d.literalStore(dataKey, mapKey, &noField); err != nil {
+		d.literalError(mapKey, literalKind(mapKey.Type()), dataKey, err)
+		return
+	}
+
+	mapElem := reflect.New(t.Elem()).Elem()
+	switch {
+	case t.Elem() == stringsType:
+		mapElem.Set(reflect.ValueOf(append([]string(nil), dataV...)))
+	case t.Elem().Kind() == reflect.String && t.Elem() != numberType:
+		mapElem.SetString(strings.Join(dataV, sep))
+	case len(dataV) > 0:
+		if err := d.literalStore(dataV[0], mapElem, &noField); err != nil {
+			d.literalError(mapElem, literalKind(mapElem.Type()), dataV[0], err)
+			return
+		}
+	}
+	v.SetMapIndex(mapKey, mapElem)
 }
 
-func (d *decodeState) addErrorContext(err error) error {
+// structPointer loads the keys starting with prefix into the struct
+// pointed to by v. Like an embedded struct pointer, a nil v is allocated
+// only when one of the keys matches a field. Nothing is loaded when no
+// key starts with prefix, which also ends the recursion of self-referencing
+// types.
+func (d *decodeState) structPointer(v reflect.Value, prefix string) {
+	if !d.hasKeyPrefix(prefix) {
+		return
+	}
+	if !v.IsNil() {
+		d.object(v.Elem(), prefix)
+		return
+	}
+
+	elem := reflect.New(v.Type().Elem())
+	matched := d.matched
+	d.object(elem.Elem(), prefix)
+	if d.matched != matched {
+		v.Set(elem)
+	}
+}
+
+// hasKeyPrefix reports whether a form key starts with prefix.
+func (d *decodeState) hasKeyPrefix(prefix string) bool {
+	prefix = d.normalizeKey(prefix)
+	for dataKey := range d.data {
+		if strings.HasPrefix(dataKey, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// structSlice loads the keys of the form "key[n].name" into the elements
+// of the struct or struct pointer slice v, growing it to fit the largest
+// index n. Elements without keys are left as they are, so nil for new
+// pointer elements; the others are allocated as needed.
+func (d *decodeState) structSlice(v reflect.Value, key string) {
+	limit := d.dec.maxSliceLen
+	if limit <= 0 {
+		limit = defaultMaxSliceLen
+	}
+	indexes, ok := d.sliceIndexes(key, limit)
+	if !ok {
+		d.saveError(&SliceLenError{Field: d.fieldPath(), Max: limit})
+		return
+	}
+	if len(indexes) == 0 {
+		return
+	}
+
+	if n := indexes[len(indexes)-1] + 1; n > v.Len() {
+		grown := reflect.MakeSlice(v.Type(), n, n)
+		reflect.Copy(grown, v)
+		v.Set(grown)
+	}
+
+	for _, i := range indexes {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+			elem = elem.Elem()
+		}
+		d.pushIndex(i)
+		d.object(elem, key+"["+strconv.Itoa(i)+"]"+d.dec.nestedSeparator)
+		d.popField()
+	}
+}
+
+// sliceTooLong reports whether a slice of n elements exceeds limit,
+// saving a SliceLenError if so. A limit of 0 or less means no limit.
+func (d *decodeState) sliceTooLong(n, limit int) bool {
+	if limit <= 0 || n <= limit {
+		return false
+	}
+	d.saveError(&SliceLenError{Field: d.fieldPath(), Max: limit})
+	return true
+}
+
+// sliceIndexes returns the distinct indexes n of the form keys
+// "key[n].name" in increasing order. If any n is limit or more, it
+// returns false instead, marking all those keys as used so that they
+// are not reported again as unknown.
+func (d *decodeState) sliceIndexes(key string, limit int) ([]int, bool) {
+	keyPrefix := d.normalizeKey(key) + "["
+	seen := make(map[int]bool)
+	var indexes []int
+	var keys []string
+	tooLong := false
+	for dataKey := range d.data {
+		rest, ok := strings.CutPrefix(dataKey, keyPrefix)
+		if !ok {
+			continue
+		}
+
+		num, rest, ok := strings.Cut(rest, "]")
+		if !ok || !strings.HasPrefix(rest, d.dec.nestedSeparator) {
+			continue
+		}
+
+		i, err := strconv.Atoi(num)
+		if err != nil || i < 0 {
+			continue
+		}
+		keys = append(keys, dataKey)
+		if i >= limit {
+			tooLong = true
+			continue
+		}
+		if !seen[i] {
+			seen[i] = true
+			indexes = append(indexes, i)
+		}
+	}
+	if tooLong {
+		for _, dataKey := range keys {
+			d.markUsed(dataKey)
+		}
+		return nil, false
+	}
+	sort.Ints(indexes)
+	return indexes, true
+}
+
+// mapValues loads the keys of the form "key[name]" into the map v,
+// converting the bracketed name and the values of each key.
+// The map is allocated only when at least one such key is present,
+// which mapValues reports. Maps of structs are loaded by structMap.
+func (d *decodeState) mapValues(v reflect.Value, key string) bool {
+	if d.isNestedStruct(indirect(v.Type().Elem())) {
+		return d.structMap(v, key)
+	}
+
+	found := false
+	keyPrefix := d.normalizeKey(key) + "["
+	for _, dataKey := range sortedKeys(d.data) {
+		if !strings.HasPrefix(dataKey, keyPrefix) || !strings.HasSuffix(dataKey, "]") {
+			continue
+		}
+
+		name := dataKey[len(keyPrefix) : len(dataKey)-1]
+		if strings.ContainsAny(name, "[]") {
+			continue
+		}
+
+		d.markUsed(dataKey)
+
+		dataV := d.data[dataKey]
+		if len(dataV) < 1 {
+			continue
+		}
+
+		found = true
+		d.pushField("[" + name + "]")
+		d.mapEntry(v, name, dataV)
+		d.popField()
+	}
+	return found
+}
+
+// structMap loads the keys of the form "key[name][field]" or
+// "key[name].field" into the struct elements of the map v, grouping them
+// by name. The rest of each key is loaded into the element of name as
+// if it were the whole form, with bracketed segments read as nested
+// field names and indexes, so "key[name][address][city]" loads into
+// the address.city field. structMap reports whether any key was found.
+func (d *decodeState) structMap(v reflect.Value, key string) bool {
+	keyPrefix := d.normalizeKey(key) + "["
+	groups := make(map[string]map[string][]string)
+	dataKeys := make(map[string]map[string]string) // group name -> sub key -> key
+	var names []string
+	for _, dataKey := range sortedKeys(d.data) {
+		rest, ok := strings.CutPrefix(dataKey, keyPrefix)
+		if !ok {
+			continue
+		}
+		name, rest, ok := strings.Cut(rest, "]")
+		if !ok || name == "" || strings.Contains(name, "[") {
+			continue
+		}
+		subKey, ok := d.mapSubKey(rest)
+		if !ok {
+			continue
+		}
+		subKey = d.normalizeKey(subKey)
+
+		if groups[name] == nil {
+			groups[name] = make(map[string][]string)
+			dataKeys[name] = make(map[string]string)
+			names = append(names, name)
+		}
+		groups[name][subKey] = d.data[dataKey]
+		dataKeys[name][subKey] = dataKey
+	}
+
+	for _, name := range names {
+		d.pushField("[" + name + "]")
+		used := d.structMapEntry(v, name, groups[name])
+		d.popField()
+
+		for subKey := range used {
+			d.markUsed(dataKeys[name][subKey])
+		}
+	}
+	return len(names) > 0
+}
+
+// mapSubKey converts the rest of a key following the name of a map
+// element, such as "[address][city]" or ".address.city", to the key of
+// a field of the element, "address.city". Numeric segments stay
+// bracketed, so "[items][0][qty]" becomes "items[0].qty".
+func (d *decodeState) mapSubKey(rest string) (string, bool) {
+	sep := d.dec.nestedSeparator
+	if sep != "" {
+		if s, ok := strings.CutPrefix(rest, sep); ok {
+			return s, s != ""
+		}
+	}
+
+	var b strings.Builder
+	for rest != "" {
+		if rest[0] != '[' {
+			return "", false
+		}
+		part, r, ok := strings.Cut(rest[1:], "]")
+		if !ok || part == "" {
+			return "", false
+		}
+		rest = r
+
+		if _, err := strconv.Atoi(part); err == nil && b.Len() > 0 {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(part)
+	}
+	return b.String(), b.Len() > 0
+}
+
+// structMapEntry loads data, the form values of the element name, into
+// the struct element of the map v under the key converted from name,
+// starting from the element already stored under it if any. In strict
+// mode it returns the keys of data that matched a field.
+func (d *decodeState) structMapEntry(v reflect.Value, name string, data map[string][]string) map[string]bool {
+	mapKey := reflect.New(v.Type().Key()).Elem()
+	if err := d.literalStore(name, mapKey, &noField); err != nil {
+		d.literalError(mapKey, literalKind(mapKey.Type()), name, err)
+		return nil
+	}
+
+	mapElem := reflect.New(v.Type().Elem()).Elem()
+	if !v.IsNil() {
+		if old := v.MapIndex(mapKey); old.IsValid() {
+			mapElem.Set(old)
+		}
+	}
+	elem := mapElem
+	if elem.Kind() == reflect.Pointer {
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+		elem = elem.Elem()
+	}
+
+	outerData, outerRaw, outerUsed, outerKnown := d.data, d.raw, d.usedKeys, d.knownKeys
+	d.data, d.raw, d.usedKeys, d.knownKeys = data, data, nil, nil
+	d.object(elem, "")
+	used := d.usedKeys
+	d.data, d.raw, d.usedKeys, d.knownKeys = outerData, outerRaw, outerUsed, outerKnown
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	v.SetMapIndex(mapKey, mapElem)
+	return used
+}
+
+// keyValues loads the "name:value" pairs of dataV, the values of a map
+// field with the kv option, into the map v. Each value is split into
+// pairs by the slice delimiter, or by commas if there is none, and each
+// pair by the key-value separator.
+func (d *decodeState) keyValues(v reflect.Value, dataV []string) {
+	sep := d.dec.sliceDelimiter
+	if sep == "" {
+		sep = ","
+	}
+	for _, item := range dataV {
+		for _, pair := range splitValue(item, sep) {
+			name, value, ok := strings.Cut(pair, d.dec.kvSeparator)
+			if !ok {
+				d.saveError(&LoadTypeError{Value: "map " + pair, Type: v.Type()})
+				continue
+			}
+			d.pushField("[" + name + "]")
+			d.mapEntry(v, name, []string{value})
+			d.popField()
+		}
+	}
+}
+
+// mapEntry stores dataV under the key converted from name into the map v,
+// allocating the map if needed. A []string element receives a copy of
+// all the values, other elements the first one.
+func (d *decodeState) mapEntry(v reflect.Value, name string, dataV []string) {
+	mapKey := reflect.New(v.Type().Key()).Elem()
+	if err := d.literalStore(name, mapKey, &noField); err != nil {
+		d.literalError(mapKey, literalKind(mapKey.Type()), name, err)
+		return
+	}
+
+	mapElem := reflect.New(v.Type().Elem()).Elem()
+	if mapElem.Type() == stringsType {
+		mapElem.Set(reflect.ValueOf(append([]string(nil), dataV...)))
+	} else if err := d.literalStore(dataV[0], mapElem, &noField); err != nil {
+		d.literalError(mapElem, literalKind(mapElem.Type()), dataV[0], err)
+		return
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	v.SetMapIndex(mapKey, mapElem)
+}
+
+// literalStore converts item according to the type of v and stores it into v.
+// The tags of f, the struct field being loaded, tune the conversion.
+// It returns errInvalidValue if the type of v is not supported.
+func (d *decodeState) literalStore(item string, v reflect.Value, f *field) error {
+	tag := f.tag
+	if conv, ok := d.dec.converter(v.Type()); ok {
+		cv, err := conv(item)
+		if err != nil {
+			return err
+		}
+		if !cv.IsValid() || !cv.Type().AssignableTo(v.Type()) {
+			return errInvalidValue
+		}
+		v.Set(cv)
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.literalStore(item, v.Elem(), f)
+	}
+
+	if v.Type() == timeType {
+		return storeTime(item, tag.Get("layout"), v)
+	}
+
+	switch v.Type() {
+	case ipType:
+		ip := net.ParseIP(item)
+		if ip == nil {
+			return errInvalidIP
+		}
+		v.Set(reflect.ValueOf(ip))
+		return nil
+	case ipNetType:
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*ipNet))
+		return nil
+	case urlType:
+		u, err := url.Parse(item)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	if v.Type() == numberType {
+		if !isValidNumber(item) {
+			return errInvalidNumber
+		}
+		v.SetString(item)
+		return nil
+	}
+
+	switch v.Type() {
+	case bigIntType:
+		n, ok := new(big.Int).SetString(item, d.intBase(tag))
+		if !ok {
+			return errInvalidNumber
+		}
+		v.Addr().Interface().(*big.Int).Set(n)
+		return nil
+	case bigFloatType:
+		f := v.Addr().Interface().(*big.Float)
+		prec := f.Prec()
+		if prec == 0 {
+			// Keep every digit of item: each takes less than 4 bits.
+			prec = max(64, 4*uint(len(item)))
+		}
+		n, _, err := big.ParseFloat(d.decimalPoint(item), 10, prec, big.ToNearestEven)
+		if err != nil {
+			return errInvalidNumber
+		}
+		f.Set(n)
+		return nil
+	}
+
+	if isTextUnmarshaler(v.Type()) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(item))
+	}
+
+	if reflect.PointerTo(v.Type()).Implements(scannerType) {
+		return v.Addr().Interface().(sql.Scanner).Scan(item)
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			dur, err := time.ParseDuration(item)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(dur))
+			return nil
+		}
+		if tag.Get("as") == "char" {
+			r, err := parseChar(item)
+			if err != nil {
+				return err
+			}
+			if v.OverflowInt(int64(r)) {
+				return errOverflow
+			}
+			v.SetInt(int64(r))
+			return nil
+		}
+		if tag.Get("coerce") == "bool" {
+			b, err := d.parseFieldBool(item, f)
+			if err != nil {
+				return err
+			}
+			if b {
+				v.SetInt(1)
+			} else {
+				v.SetInt(0)
+			}
+			return nil
+		}
+		if as := tag.Get("as"); as == "unix" || as == "unixmilli" {
+			n, err := d.parseUnix(item, as, tag)
+			if err != nil {
+				return err
+			}
+			if v.OverflowInt(n) {
+				return errOverflow
+			}
+			v.SetInt(n)
+			return nil
+		}
+		n, err := strconv.ParseInt(item, d.intBase(tag), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return errOverflow
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if tag.Get("as") == "char" {
+			r, err := parseChar(item)
+			if err != nil {
+				return err
+			}
+			if v.OverflowUint(uint64(r)) {
+				return errOverflow
+			}
+			v.SetUint(uint64(r))
+			return nil
+		}
+		if tag.Get("coerce") == "bool" {
+			b, err := d.parseFieldBool(item, f)
+			if err != nil {
+				return err
+			}
+			if b {
+				v.SetUint(1)
+			} else {
+				v.SetUint(0)
+			}
+			return nil
+		}
+		n, err := strconv.ParseUint(item, d.intBase(tag), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(n) {
+			return errOverflow
+		}
+		v.SetUint(n)
+	case reflect.Bool:
+		b, err := d.parseFieldBool(item, f)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		// ParseFloat accepts hex floats such as "0x1p1023" and rounds n
+		// to the bit size of v, so every finite value passes OverflowFloat.
+		n, err := strconv.ParseFloat(d.decimalPoint(item), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if v.OverflowFloat(n) {
+			return errOverflow
+		}
+		v.SetFloat(n)
+	case reflect.Complex64, reflect.Complex128:
+		n, err := strconv.ParseComplex(item, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		if v.OverflowComplex(n) {
+			return errOverflow
+		}
+		v.SetComplex(n)
+	case reflect.String:
+		// An empty value is left to the required and skip-empty rules.
+		if enum, ok := tag.Lookup("enum"); ok && item != "" && !inEnum(enum, item) {
+			return errInvalidValue
+		}
+		v.SetString(item)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return errInvalidValue
+		}
+		v.Set(reflect.ValueOf(item))
+	case reflect.Slice:
+		if !isBytes(v.Type()) {
+			return errInvalidValue
+		}
+		b, err := decodeBytes(item, tag.Get("encoding"))
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+	default:
+		return errInvalidValue
+	}
+
+	return nil
+}
+
+// decodeBytes decodes s using the named encoding: "hex", or "base64"
+// when encoding is empty.
+func decodeBytes(s, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "base64":
+		return base64.StdEncoding.DecodeString(s)
+	case "hex":
+		return hex.DecodeString(s)
+	}
+	return nil, errors.New("form: unknown encoding " + encoding)
+}
+
+// isValidNumber reports whether s is a valid JSON number literal.
+func isValidNumber(s string) bool {
+	// This function implements the JSON numbers grammar.
+	// See https://tools.ietf.org/html/rfc7159#section-6
+	// and https://www.json.org/img/number.png
+
+	if s == "" {
+		return false
+	}
+
+	// Optional -
+	if s[0] == '-' {
+		s = s[1:]
+		if s == "" {
+			return false
+		}
+	}
+
+	// Digits
+	switch {
+	default:
+		return false
+
+	case s[0] == '0':
+		s = s[1:]
+
+	case '1' <= s[0] && s[0] <= '9':
+		s = s[1:]
+		for len(s) > 0 && '0' <= s[0] && s[0] <= '9' {
+			s = s[1:]
+		}
+	}
+
+	// . followed by 1 or more digits.
+	if len(s) >= 2 && s[0] == '.' && '0' <= s[1] && s[1] <= '9' {
+		s = s[2:]
+		for len(s) > 0 && '0' <= s[0] && s[0] <= '9' {
+			s = s[1:]
+		}
+	}
+
+	// e or E followed by an optional - or + and
+	// 1 or more digits.
+	if len(s) >= 2 && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if s[0] == '+' || s[0] == '-' {
+			s = s[1:]
+			if s == "" {
+				return false
+			}
+		}
+		for len(s) > 0 && '0' <= s[0] && s[0] <= '9' {
+			s = s[1:]
+		}
+	}
+
+	// Make sure we are at the end.
+	return s == ""
+}
+
+// parseBool returns the boolean value represented by s, ignoring case.
+// It accepts "true", "1", "on", "yes" and "y" as true, and "false", "0",
+// "off", "no", "n" and the empty string as false.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "1", "on", "yes", "y":
+		return true, nil
+	case "false", "0", "off", "no", "n", "":
+		return false, nil
+	}
+	return false, errInvalidBool
+}
+
+// inEnum reports whether item is one of the comma-separated values
+// of the enum struct tag.
+func inEnum(enum, item string) bool {
+	for _, s := range strings.Split(enum, ",") {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFieldBool is like parseBool but uses the truthy= and falsy=
+// options of the field f, when present, as the values accepted as true
+// and false respectively, ignoring case. The values are compared as
+// strings, not parsed, so a set may hold tokens such as "-1".
+func (d *decodeState) parseFieldBool(item string, f *field) (bool, error) {
+	truthy, falsy := f.truthy, f.falsy
+	if truthy == nil && falsy == nil {
+		return parseBool(item)
+	}
+
+	for _, s := range truthy {
+		if strings.EqualFold(item, s) {
+			return true, nil
+		}
+	}
+	for _, s := range falsy {
+		if strings.EqualFold(item, s) {
+			return false, nil
+		}
+	}
+
+	b, err := parseBool(item)
+	if err != nil || b && truthy != nil || !b && falsy != nil {
+		return false, errInvalidBool
+	}
+	return b, nil
+}
+
+// literalError saves a LoadTypeError for item, the form value of kind
+// that failed to load into v with err. In lenient mode v is set to its
+// zero value instead.
+func (d *decodeState) literalError(v reflect.Value, kind, item string, err error) {
+	if d.dec.lenient {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+	d.saveError(&LoadTypeError{Value: typeErrorValue(kind, item, err), Type: v.Type()})
+}
+
+// typeErrorValue describes item, a form value that failed to load with
+// err, for use in LoadTypeError.Value: kind followed by item, with
+// "overflow" in between if item is out of range, as in "number overflow 1e400".
+func typeErrorValue(kind, item string, err error) string {
+	if errors.Is(err, errOverflow) || errors.Is(err, strconv.ErrRange) {
+		return kind + " overflow " + item
+	}
+	return kind + " " + item
+}
+
+// literalKind describes a form value loaded into a value of type t,
+// for use in LoadTypeError.Value.
+func literalKind(t reflect.Type) string {
+	switch {
+	case t == timeType:
+		return "time"
+	case t == durationType:
+		return "duration"
+	case t == urlType:
+		return "url"
+	case t == numberType, t == bigIntType, t == bigFloatType:
+		return "number"
+	case isTextUnmarshaler(t):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return "number"
+	case reflect.Bool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// sortedKeys returns the keys of data in increasing order.
+func sortedKeys(data map[string][]string) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitValue splits s around sep. An empty s yields no elements
+// and a trailing separator does not add an empty element.
+func splitValue(s, sep string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(s, sep)
+	if parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+// parseChar returns the only character of s, as loaded into integer
+// fields tagged as:"char".
+func parseChar(s string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError || size != len(s) {
+		return 0, errInvalidChar
+	}
+	return r, nil
+}
+
+// parseUnix parses s, an integer or a time, as a Unix time in seconds,
+// or in milliseconds when as is "unixmilli". A time is parsed with the
+// layouts of the layout tag, time.RFC3339 by default.
+func (d *decodeState) parseUnix(s, as string, tag reflect.StructTag) (int64, error) {
+	if n, err := strconv.ParseInt(s, d.intBase(tag), 64); err == nil {
+		return n, nil
+	}
+
+	var tm time.Time
+	if err := storeTime(s, tag.Get("layout"), reflect.ValueOf(&tm).Elem()); err != nil {
+		return 0, err
+	}
+	if as == "unixmilli" {
+		return tm.UnixMilli(), nil
+	}
+	return tm.Unix(), nil
+}
+
+// storeTime parses s using layout (time.RFC3339 by default) and stores
+// the result into v. layout may list several layouts separated by "|",
+// which are tried in order; the error of the last one is returned if
+// none of them parses s.
+func storeTime(s, layout string, v reflect.Value) error {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	var err error
+	for _, l := range strings.Split(layout, "|") {
+		var tm time.Time
+		if tm, err = time.Parse(l, s); err == nil {
+			v.Set(reflect.ValueOf(tm))
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (d *decodeState) saveError(err error) {
+	d.errCount++
+	if d.dec.collectErrors {
+		d.errs = append(d.errs, d.addErrorContext(err))
+		return
+	}
+
+	if d.savedError == nil {
+		d.savedError = d.addErrorContext(err)
+	}
+}
+
+// newDecodeState returns a decodeState from the pool of dec,
+// initialized to load data.
+func (dec *Decoder) newDecodeState(ctx context.Context, data map[string][]string) *decodeState {
+	d, _ := dec.states.Get().(*decodeState)
+	if d == nil {
+		d = new(decodeState)
+	}
+	d.init(ctx, dec, data)
+	return d
+}
+
+// freeDecodeState returns d to the pool of dec once its results have
+// been read. The references d holds to the loaded data and to the errors
+// are dropped first, so that they are neither kept alive nor shared with
+// a later call; only the backing arrays of its stacks are reused.
+func (dec *Decoder) freeDecodeState(d *decodeState) {
+	d.ctx = nil
+	d.dec = nil
+	d.data = nil
+	d.raw = nil
+	d.savedError = nil
+	d.errs = nil
+	d.usedKeys = nil
+	d.files = nil
+	d.structType = nil
+	clear(d.knownKeys)
+	d.knownKeys = d.knownKeys[:0]
+	clear(d.fieldStack)
+	d.fieldStack = d.fieldStack[:0]
+	dec.states.Put(d)
+}
+
+func (d *decodeState) init(ctx context.Context, dec *Decoder, data map[string][]string) {
+	d.ctx = ctx
+	d.dec = dec
+	d.savedError = nil
+	d.errs = nil
+	d.usedKeys = nil
+	d.files = nil
+	d.knownKeys = d.knownKeys[:0]
+	d.matched = 0
+	d.fieldStack = d.fieldStack[:0]
+	d.structType = nil
+	d.errCount = 0
+	d.msg = ""
+	d.populated = 0
+	d.data = data
+	d.raw = data
+
+	if dec.caseInsensitive || dec.trimSpace || dec.prefix != "" || dec.keyNormalizer != nil {
+		d.data = make(map[string][]string, len(data))
+		prefix := d.normalizeKey(dec.prefix)
+		for _, k := range sortedKeys(data) {
+			nk := k
+			if dec.keyNormalizer != nil {
+				nk = dec.keyNormalizer(nk)
+			}
+			nk, ok := strings.CutPrefix(d.normalizeKey(nk), prefix)
+			if !ok {
+				continue
+			}
+			if _, ok := d.data[nk]; !ok {
+				d.data[nk] = d.normalizeValues(data[k])
+			}
+		}
+	}
+}
+
+// normalizeValues returns the values as seen by the decoder, with
+// surrounding white space removed if the Decoder trims space.
+func (d *decodeState) normalizeValues(values []string) []string {
+	if !d.dec.trimSpace {
+		return values
+	}
+
+	trimmed := make([]string, len(values))
+	for i, s := range values {
+		trimmed[i] = strings.TrimSpace(s)
+	}
+	return trimmed
+}
+
+// decimalPoint returns the float value item with the decimal separator
+// of the Decoder replaced by a '.'.
+func (d *decodeState) decimalPoint(item string) string {
+	if sep := d.dec.decimalSep; sep != 0 && sep != '.' {
+		return strings.ReplaceAll(item, string(sep), ".")
+	}
+	return item
+}
+
+// intBase returns the base for parsing integers into a field with the
+// given tag.
+func (d *decodeState) intBase(tag reflect.StructTag) int {
+	if s, ok := tag.Lookup("base"); ok {
+		if base, err := strconv.Atoi(s); err == nil {
+			return base
+		}
+	}
+	return d.dec.intBase
+}
+
+// hasConverter reports whether a Converter is registered for type t.
+func (d *decodeState) hasConverter(t reflect.Type) bool {
+	_, ok := d.dec.converter(t)
+	return ok
+}
+
+// isNestedStruct is like the isNestedStruct function but also excludes
+// types with a registered Converter.
+func (d *decodeState) isNestedStruct(t reflect.Type) bool {
+	return isNestedStruct(t) && !d.hasConverter(t)
+}
+
+// normalizeKey maps a form key to its form in d.data. Case folding
+// leaves bracketed segments, such as map keys, as they are.
+func (d *decodeState) normalizeKey(key string) string {
+	if !d.dec.caseInsensitive {
+		return key
+	}
+	if !strings.Contains(key, "[") {
+		return strings.ToLower(key)
+	}
+
+	var b strings.Builder
+	for {
+		before, rest, ok := strings.Cut(key, "[")
+		b.WriteString(strings.ToLower(before))
+		if !ok {
+			return b.String()
+		}
+		inner, after, ok := strings.Cut(rest, "]")
+		b.WriteString("[" + inner)
+		if !ok {
+			return b.String()
+		}
+		b.WriteString("]")
+		key = after
+	}
+}
+
+// isNull reports whether the form value s is the null value, which
+// leaves the field it is loaded into unchanged.
+func (d *decodeState) isNull(s string) bool {
+	return s == d.dec.nullValue
+}
+
+// nullKey reports whether the only form value of key is the null value.
+// It is used for fields loaded from prefixed keys, which can still be
+// set to null through their own key.
+func (d *decodeState) nullKey(key string) bool {
+	key = d.normalizeKey(key)
+	dataV := d.data[key]
+	if len(dataV) != 1 || !d.isNull(dataV[0]) {
+		return false
+	}
+	d.markUsed(key)
+	return true
+}
+
+// lookup returns the form values of key.
+func (d *decodeState) lookup(key string) ([]string, bool) {
+	key = d.normalizeKey(key)
+	dataV, ok := d.data[key]
+	if ok {
+		d.markUsed(key)
+	}
+	return dataV, ok
+}
+
+// markUsed records that the d.data key matched a field.
+func (d *decodeState) markUsed(key string) {
+	d.matched++
+	if !d.dec.strict && !d.dec.suggestUnknown {
+		return
+	}
+	if d.usedKeys == nil {
+		d.usedKeys = make(map[string]bool)
+	}
+	d.usedKeys[key] = true
+}
+
+// suggestKey returns the first field key, in sorted order, within one
+// edit of the unknown form key, or "" if there is none or suggestions
+// are disabled.
+func (d *decodeState) suggestKey(key string) string {
+	if !d.dec.suggestUnknown {
+		return ""
+	}
+	known := append([]string(nil), d.knownKeys...)
+	sort.Strings(known)
+	for _, k := range known {
+		if oneEditApart(key, k) {
+			return k
+		}
+	}
+	return ""
+}
+
+// oneEditApart reports whether a and b are at Levenshtein distance one,
+// that is whether inserting, deleting or replacing a single character
+// of a gives b.
+func oneEditApart(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 || a == b {
+		return false
+	}
+
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
+
+// pushField appends the path segment name to the field stack.
+func (d *decodeState) pushField(name string) {
+	d.fieldStack = append(d.fieldStack, name)
+}
+
+// pushIndex appends the path segment of the slice index i.
+func (d *decodeState) pushIndex(i int) {
+	d.pushField("[" + strconv.Itoa(i) + "]")
+}
+
+// popField removes the last path segment from the field stack.
+func (d *decodeState) popField() {
+	d.fieldStack = d.fieldStack[:len(d.fieldStack)-1]
+}
+
+// fieldPath returns the path of the value being loaded, such as
+// "orders[2].items[0].price", with the segments joined by the
+// nested separator.
+func (d *decodeState) fieldPath() string {
+	var b strings.Builder
+	for i, name := range d.fieldStack {
+		if i > 0 && !strings.HasPrefix(name, "[") {
+			b.WriteString(d.dec.nestedSeparator)
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// addErrorContext fills in the struct name of a LoadTypeError from the
+// value being loaded, and the field path and the message of the msg=
+// option of its field in a LoadTypeError or MissingFieldError, keeping
+// those already set. Other errors are returned unchanged.
+func (d *decodeState) addErrorContext(err error) error {
+	var missingErr *MissingFieldError
+	if errors.As(err, &missingErr) {
+		if missingErr.Msg == "" {
+			missingErr.Msg = d.msg
+		}
+		if missingErr.Field == "" {
+			missingErr.Field = d.fieldPath()
+		}
+	}
+
+	var typeErr *LoadTypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	if typeErr.Msg == "" {
+		typeErr.Msg = d.msg
+	}
+	if typeErr.Struct == "" && d.structType != nil {
+		typeErr.Struct = d.structType.Name()
+	}
+	if typeErr.Field == "" && len(d.fieldStack) > 0 {
+		typeErr.Field = d.fieldPath()
+	}
 	return err
 }
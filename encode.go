@@ -0,0 +1,126 @@
+// Copyright 2023 Urvantsev Evgenii. All rights reserved.
+// Use of this source code is governed by a BSD3-style
+// license that can be found in the LICENSE file.
+
+package form
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// An UnsupportedTypeError is returned by Encode when attempting
+// to encode an unsupported value type.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e.Type == nil {
+		return "form: unsupported type: nil"
+	}
+	return "form: unsupported type: " + e.Type.String()
+}
+
+// Encode returns the form values of v, which must be a struct or a pointer
+// to a struct. Fields are keyed using the same "request" tag rules as Load.
+//
+// Slices produce one value per element, nil pointers are omitted,
+// bools are encoded as "true" or "false" and time.Time as time.RFC3339.
+// The "omitempty" tag option omits the field if it has an empty value:
+// false, 0, a nil pointer or interface, and an empty string, slice or map.
+func Encode(v any) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, &UnsupportedTypeError{reflect.TypeOf(v)}
+	}
+
+	values := make(url.Values)
+	if err := encodeStruct(values, rv); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func encodeStruct(values url.Values, v reflect.Value) error {
+	for _, field := range cachedFields(v.Type(), defaultTagName) {
+		fieldValue, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// The field is promoted through a nil embedded pointer.
+			continue
+		}
+		if field.options.Contains("omitempty") && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				continue
+			}
+			fieldValue = fieldValue.Elem()
+		}
+
+		if fieldValue.Kind() == reflect.Slice || fieldValue.Kind() == reflect.Array {
+			for j := 0; j < fieldValue.Len(); j++ {
+				s, err := encodeValue(fieldValue.Index(j))
+				if err != nil {
+					return err
+				}
+				values.Add(field.name, s)
+			}
+			continue
+		}
+
+		s, err := encodeValue(fieldValue)
+		if err != nil {
+			return err
+		}
+		values.Add(field.name, s)
+	}
+
+	return nil
+}
+
+func encodeValue(v reflect.Value) (string, error) {
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.String:
+		return v.String(), nil
+	default:
+		return "", &UnsupportedTypeError{v.Type()}
+	}
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package form
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEncodeObj struct {
+	Name      string        `request:"name"`
+	Age       int           `request:"age"`
+	Count     uint          `request:"count"`
+	Price     float64       `request:"price"`
+	Active    bool          `request:"active"`
+	Tags      []string      `request:"tags"`
+	CreatedAt time.Time     `request:"created_at"`
+	Timeout   time.Duration `request:"timeout"`
+	Note      *string       `request:"note"`
+	Plain     string
+	hidden    string
+}
+
+func TestEncode_Successfully(t *testing.T) {
+	obj := testEncodeObj{
+		Name:      "bob",
+		Age:       -3,
+		Count:     7,
+		Price:     1.5,
+		Active:    true,
+		Tags:      []string{"a", "b"},
+		CreatedAt: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC),
+		Timeout:   90 * time.Second,
+		Plain:     "x",
+		hidden:    "secret",
+	}
+
+	values, err := Encode(&obj)
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"name":       {"bob"},
+		"age":        {"-3"},
+		"count":      {"7"},
+		"price":      {"1.5"},
+		"active":     {"true"},
+		"tags":       {"a", "b"},
+		"created_at": {"2023-01-02T15:04:05Z"},
+		"timeout":    {"1m30s"},
+		"Plain":      {"x"},
+	}, values)
+
+	var decoded testEncodeObj
+	assert.NoError(t, Load(values, &decoded))
+	obj.hidden = ""
+	assert.Equal(t, obj, decoded)
+}
+
+func TestEncode_UnsupportedType(t *testing.T) {
+	_, err := Encode(42)
+
+	var typeErr *UnsupportedTypeError
+	assert.ErrorAs(t, err, &typeErr)
+}
+
+type testOmitEmptyObj struct {
+	Page   int      `request:"page,omitempty"`
+	Query  string   `request:"q,omitempty"`
+	Active bool     `request:"active,omitempty"`
+	Limit  *int     `request:"limit,omitempty"`
+	Tags   []string `request:"tags,omitempty"`
+	Sort   string   `request:"sort"`
+}
+
+func TestEncode_OmitEmpty(t *testing.T) {
+	values, err := Encode(testOmitEmptyObj{})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"sort": {""}}, values)
+
+	limit := 0
+	values, err = Encode(testOmitEmptyObj{Page: 2, Active: true, Limit: &limit, Tags: []string{"a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"page":   {"2"},
+		"active": {"true"},
+		"limit":  {"0"},
+		"tags":   {"a"},
+		"sort":   {""},
+	}, values)
+}
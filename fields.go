@@ -0,0 +1,233 @@
+// Copyright 2023 Urvantsev Evgenii. All rights reserved.
+// Use of this source code is governed by a BSD3-style
+// license that can be found in the LICENSE file.
+
+package form
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A field describes a struct field loaded from form values.
+type field struct {
+	name    string   // form key relative to the enclosing struct
+	named   bool     // name comes from the tag rather than the Go field name
+	aliases []string // alternative form keys, tried in order after name
+	index   []int    // index sequence for reflect.Value.FieldByIndex
+	typ     reflect.Type
+	tag     reflect.StructTag
+	options tagOptions
+
+	required     bool
+	hasDefault   bool
+	defaultValue string
+	msg          string   // message of the errors of the field, from msg=
+	truthy       []string // values accepted as true, from truthy=
+	falsy        []string // values accepted as false, from falsy=
+}
+
+// typeFields returns the fields of the struct type t that can be loaded
+// from form values, with their keys taken from the tagName tag.
+//
+// The fields of an untagged anonymous struct or struct pointer field, and
+// of any struct field with the "squash" option, are promoted into t,
+// following the encoding/json rules: when several fields
+// share a key, the least nested one wins, and among equally nested ones
+// the first declared. A struct type already being expanded is not promoted
+// again, so that a type embedding or squashing itself loads it as a nested
+// field.
+func typeFields(t reflect.Type, tagName string) []field {
+	var all []field
+	collectFields(&all, t, tagName, nil, map[reflect.Type]bool{})
+
+	fields := make([]field, 0, len(all))
+	seen := make(map[string]int, len(all))
+	for _, f := range all {
+		i, ok := seen[f.name]
+		if !ok {
+			seen[f.name] = len(fields)
+			fields = append(fields, f)
+			continue
+		}
+		if len(f.index) < len(fields[i].index) {
+			fields[i] = f
+		}
+	}
+	return fields
+}
+
+// collectFields appends the loadable fields of the struct type t to fields,
+// descending into untagged anonymous and squashed struct fields.
+// index is the index sequence of t within the root struct.
+func collectFields(fields *[]field, t reflect.Type, tagName string, index []int, visiting map[reflect.Type]bool) {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts := parseTag(sf.Tag.Get(tagName))
+		opts, msg := opts.cutMessage()
+		if name == "-" {
+			continue
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+				if !sf.IsExported() {
+					// Nil pointers to unexported embedded structs
+					// cannot be allocated.
+					continue
+				}
+			}
+			// A struct embedding itself, directly or not, is kept as
+			// a field of its own rather than expanded again.
+			if ft.Kind() == reflect.Struct && !visiting[ft] {
+				collectFields(fields, ft, tagName, fieldIndex, visiting)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if opts.Contains("squash") {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !visiting[ft] {
+				collectFields(fields, ft, tagName, fieldIndex, visiting)
+				continue
+			}
+		}
+
+		named := name != ""
+		if !named {
+			name = sf.Name
+		}
+
+		defaultValue, hasDefault := sf.Tag.Lookup("default")
+		*fields = append(*fields, field{
+			name:         name,
+			named:        named,
+			aliases:      opts.Names(),
+			index:        fieldIndex,
+			typ:          sf.Type,
+			tag:          sf.Tag,
+			options:      opts,
+			required:     opts.Contains("required"),
+			hasDefault:   hasDefault,
+			defaultValue: defaultValue,
+			msg:          msg,
+			truthy:       opts.Values("truthy"),
+			falsy:        opts.Values("falsy"),
+		})
+	}
+}
+
+type fieldCacheKey struct {
+	t       reflect.Type
+	tagName string
+}
+
+var fieldCache sync.Map // map[fieldCacheKey][]field
+
+// cachedFields is like typeFields but uses a cache to avoid repeated work.
+func cachedFields(t reflect.Type, tagName string) []field {
+	key := fieldCacheKey{t: t, tagName: tagName}
+	if f, ok := fieldCache.Load(key); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(key, typeFields(t, tagName))
+	return f.([]field)
+}
+
+// FieldKeys returns the form keys that Decode matches against the fields
+// of v, a struct or a pointer to a struct, in field order. A field with
+// aliases contributes each of them after its name. The keys of a nested
+// struct carry its prefix, and placeholders stand for the variable part
+// of the other nested keys: "items[n].name" for a slice of structs and
+// "attrs[key]" for a map. The "_raw" map field is omitted, as are the
+// fields of a struct type nested within itself, listed at its first level.
+// It returns nil if v is not a struct or a pointer to one.
+func (dec *Decoder) FieldKeys(v any) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	dec.appendFieldKeys(&keys, t, dec.prefix, map[reflect.Type]bool{})
+	return keys
+}
+
+// FieldKeys returns the form keys matched by the default Decoder
+// against the fields of v.
+func FieldKeys(v any) []string {
+	return defaultDecoder.FieldKeys(v)
+}
+
+// appendFieldKeys appends the form keys of the fields of the struct
+// type t, looked up with prefix prepended, to keys. visiting holds the
+// struct types being listed, so that recursive types are listed once.
+func (dec *Decoder) appendFieldKeys(keys *[]string, t reflect.Type, prefix string, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	nested := func(t reflect.Type) bool {
+		_, ok := dec.converter(t)
+		return isNestedStruct(t) && !ok
+	}
+
+	fields := cachedFields(t, dec.tagName)
+	for i := range fields {
+		f := &fields[i]
+		name := f.name
+		if !f.named && dec.nameMapper != nil {
+			name = dec.nameMapper(name)
+		}
+		key := prefix + name
+
+		ft := f.typ
+		_, hasConverter := dec.converter(ft)
+		switch {
+		case ft == fileHeaderType || ft == fileHeadersType:
+			*keys = append(*keys, key)
+			continue
+		case !hasConverter && nested(indirect(ft)):
+			dec.appendFieldKeys(keys, indirect(ft), key+dec.nestedSeparator, visiting)
+			continue
+		case ft.Kind() == reflect.Map && name == rawFieldName:
+			continue
+		case ft.Kind() == reflect.Map && !hasConverter && !f.options.Contains("kv"):
+			if elem := indirect(ft.Elem()); nested(elem) {
+				dec.appendFieldKeys(keys, elem, key+"[key]"+dec.nestedSeparator, visiting)
+			} else {
+				*keys = append(*keys, key+"[key]")
+			}
+			continue
+		case ft.Kind() == reflect.Slice && !hasConverter && nested(indirect(ft.Elem())):
+			dec.appendFieldKeys(keys, indirect(ft.Elem()), key+"[n]"+dec.nestedSeparator, visiting)
+			continue
+		}
+
+		*keys = append(*keys, key)
+		for _, alias := range f.aliases {
+			*keys = append(*keys, prefix+alias)
+		}
+	}
+}
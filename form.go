@@ -4,8 +4,548 @@
 
 package form
 
-func Load(data map[string][]string, v any) error {
-	var d decodeState
-	d.init(data)
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"reflect"
+	"sync"
+	"unicode"
+)
+
+// ErrNilData is returned when decoding a nil form value map.
+// An empty map is valid and leaves the target unchanged.
+var ErrNilData = errors.New("form: Load(nil data)")
+
+// ErrBodyTooLarge is returned by DecodeReader when the form-encoded data
+// exceeds the size set with Decoder.SetMaxBodySize.
+var ErrBodyTooLarge = errors.New("form: body too large")
+
+// Unmarshaler is the interface implemented by types that can load
+// themselves from all the form values of their key.
+//
+// It takes precedence over encoding.TextUnmarshaler, which only receives
+// the first value. UnmarshalForm is called with the raw values, including
+// the null value (see Decoder.SetNullValue), and any error it returns
+// is reported as is.
+type Unmarshaler interface {
+	UnmarshalForm(values []string) error
+}
+
+// Validator is the interface implemented by types that check themselves
+// once loaded, for example to compare several fields. Validate is called
+// on the pointer passed to Decode, and its error returned as is, only when
+// loading reported no errors.
+type Validator interface {
+	Validate() error
+}
+
+// A Converter converts a form value into a value of the type
+// it is registered for.
+type Converter func(value string) (reflect.Value, error)
+
+// defaultTagName is the struct tag key used to look up form keys.
+const defaultTagName = "request"
+
+// defaultMaxBodySize is the default limit of DecodeReader,
+// the same as the one of http.Request.ParseForm.
+const defaultMaxBodySize = 10 << 20
+
+// defaultMaxSliceLen is the default maximum length of slice fields. It also
+// bounds the growth of a slice of structs loaded from indexed keys when the
+// Decoder sets no maximum, since a single key such as
+// "items[3000000000].qty" would otherwise allocate billions of elements,
+// a failure that cannot be recovered from.
+const defaultMaxSliceLen = 1000
+
+// defaultMaxMemory is the default memory limit of DecodeMultipart,
+// the same as the one of http.Request.FormFile.
+const defaultMaxMemory = 32 << 20
+
+// A Decoder loads form values into Go structs.
+// Each Decoder carries its own settings, so handlers that need different
+// behavior can use separate instances.
+//
+// A Decoder is safe for concurrent use by multiple goroutines once
+// configured. RegisterConverter may be called at any time; the other
+// setters must not be called concurrently with decoding.
+type Decoder struct {
+	settings
+
+	mu         sync.RWMutex // guards converters and impls
+	converters map[reflect.Type]Converter
+	impls      map[reflect.Type]interfaceImpl
+
+	states sync.Pool // of *decodeState, reused across calls
+}
+
+// settings holds the options of a Decoder.
+type settings struct {
+	tagName         string
+	sliceDelimiter  string
+	nestedSeparator string
+	collectErrors   bool
+	caseInsensitive bool
+	strict          bool
+	skipEmpty       bool
+	trimSpace       bool
+	intBase         int
+	decimalSep      rune
+	nullValue       string
+	nameMapper      func(string) string
+	prefix          string
+	arrayBrackets   bool
+	rejectDups      bool
+	sliceAppend     bool
+	keyNormalizer   func(string) string
+	maxBodySize     int64
+	maxSliceLen     int
+	suggestUnknown  bool
+	maxMemory       int64
+	kvSeparator     string
+	lenient         bool
+}
+
+// NewDecoder returns a Decoder with the default settings.
+func NewDecoder() *Decoder {
+	return &Decoder{settings: settings{
+		tagName:         defaultTagName,
+		nestedSeparator: ".",
+		intBase:         10,
+		nullValue:       "null",
+		maxBodySize:     defaultMaxBodySize,
+		maxMemory:       defaultMaxMemory,
+		maxSliceLen:     defaultMaxSliceLen,
+		kvSeparator:     ":",
+	}}
+}
+
+// Clone returns a copy of the Decoder with the same settings and its own
+// converter registry, so a base Decoder can be adjusted per route, for
+// example made strict, without affecting the other users of the base.
+// Clone may be called concurrently with decoding and RegisterConverter.
+func (dec *Decoder) Clone() *Decoder {
+	dec.mu.RLock()
+	defer dec.mu.RUnlock()
+
+	c := &Decoder{settings: dec.settings}
+	if dec.converters != nil {
+		c.converters = make(map[reflect.Type]Converter, len(dec.converters))
+		for t, fn := range dec.converters {
+			c.converters[t] = fn
+		}
+	}
+	if dec.impls != nil {
+		c.impls = make(map[reflect.Type]interfaceImpl, len(dec.impls))
+		for t, impl := range dec.impls {
+			c.impls[t] = impl
+		}
+	}
+	return c
+}
+
+// WithPrefix returns a copy of the Decoder that only loads the form keys
+// starting with prefix, matching fields against the rest of each key.
+// With "billing_", "billing_city" loads into the field keyed "city".
+// Keys without the prefix are ignored, so one form can be loaded into
+// several structs.
+func (dec *Decoder) WithPrefix(prefix string) *Decoder {
+	c := dec.Clone()
+	c.prefix = prefix
+	return c
+}
+
+// SetTagName sets the struct tag key holding the form key of a field.
+// Fields without the tag are matched by their Go name. The default is "request".
+func (dec *Decoder) SetTagName(name string) {
+	dec.tagName = name
+}
+
+// SetSliceDelimiter sets the separator used to split a single form value
+// into the elements of a slice field, so "ids=1,2,3" loads as three elements.
+// Repeated keys are never split. An empty delimiter, the default,
+// disables splitting.
+func (dec *Decoder) SetSliceDelimiter(sep string) {
+	dec.sliceDelimiter = sep
+}
+
+// SetNestedSeparator sets the separator joining the form key of a struct
+// field with the keys of its own fields, so "address.city" loads into
+// the City field of an Address field. The default is ".".
+func (dec *Decoder) SetNestedSeparator(sep string) {
+	dec.nestedSeparator = sep
+}
+
+// SetCollectErrors makes Decode report every field error as LoadErrors
+// instead of stopping at the first one, which stays the default.
+func (dec *Decoder) SetCollectErrors(on bool) {
+	dec.collectErrors = on
+}
+
+// SetCaseInsensitive makes form keys match field keys regardless of case.
+// Bracketed map keys keep their case, so "Filter[UserName]" loads into the
+// "filter" map under "UserName". When several form keys differ only in
+// case, the first one in sorted order wins. Matching is case-sensitive by
+// default.
+func (dec *Decoder) SetCaseInsensitive(on bool) {
+	dec.caseInsensitive = on
+}
+
+// SetStrict makes Decode report an UnknownFieldError for every form key
+// that does not match any field. Unknown keys are ignored by default.
+func (dec *Decoder) SetStrict(on bool) {
+	dec.strict = on
+}
+
+// SetSuggestUnknown makes Decode report an UnknownFieldError for every
+// form key one edit away from a field key or alias, such as "usr_id" for
+// "user_id", with that field key as its Suggestion. Other unknown keys,
+// like tracking parameters, are still ignored unless the Decoder is
+// strict, in which case their errors carry a Suggestion when one is found.
+// Suggestions are off by default.
+func (dec *Decoder) SetSuggestUnknown(on bool) {
+	dec.suggestUnknown = on
+}
+
+// SetLenient makes Decode ignore form values that cannot be converted to
+// the type of their field, slice element or map entry: the field or element
+// is set to its zero value, map entries are skipped, and no LoadTypeError
+// is reported for them. A nil pointer field stays nil, while the value
+// a non-nil one points to is set to its zero value. Other errors, such as those of required fields,
+// unknown keys or Unmarshaler implementations, are still reported.
+// By default every conversion failure is reported as a LoadTypeError.
+func (dec *Decoder) SetLenient(on bool) {
+	dec.lenient = on
+}
+
+// SetSkipEmpty makes Decode treat an empty value of a non-slice field
+// as a missing key, leaving the field at its prior value unless the field
+// has a default. By default an empty value is converted like any other.
+func (dec *Decoder) SetSkipEmpty(on bool) {
+	dec.skipEmpty = on
+}
+
+// SetTrimSpace makes Decode remove leading and trailing white space from
+// every form value, including slice elements, before converting it.
+// Values are used exactly as received by default.
+func (dec *Decoder) SetTrimSpace(on bool) {
+	dec.trimSpace = on
+}
+
+// SetArrayBrackets makes slice and array fields also load the keys
+// with "[]" appended, as sent by PHP and Rails clients, so "tags[]=a&tags[]=b"
+// loads into the field keyed "tags". The values of "tags" come first
+// when both keys are present. Such keys are ignored by default.
+func (dec *Decoder) SetArrayBrackets(on bool) {
+	dec.arrayBrackets = on
+}
+
+// SetSliceAppend makes Decode append the values of a slice field to
+// the elements it already holds. By default the field is replaced by
+// a new slice holding only the values. Array fields are always loaded
+// element by element from the first one.
+func (dec *Decoder) SetSliceAppend(on bool) {
+	dec.sliceAppend = on
+}
+
+// SetRejectDuplicates makes Decode report a DuplicateValueError when
+// a non-slice field receives more than one value, instead of loading
+// the first one as by default. Unmarshaler and interface fields still
+// receive all the values.
+func (dec *Decoder) SetRejectDuplicates(on bool) {
+	dec.rejectDups = on
+}
+
+// SetKeyNormalizer sets fn to rewrite the form keys before they are
+// matched against field keys, for example to strip a prefix and replace
+// dashes with underscores. fn runs once per form key when Decode builds
+// its lookup table, not once per field, and before case folding and
+// the prefix of WithPrefix. When fn maps several keys to the same one,
+// the first in sorted order wins.
+func (dec *Decoder) SetKeyNormalizer(fn func(string) string) {
+	dec.keyNormalizer = fn
+}
+
+// SetKeyValueSeparator sets the separator between the name and the value
+// of the pairs loaded into a map field with the kv option, as in
+// `request:"params,kv"` loading "params=a:1,b:2". The default is ":".
+func (dec *Decoder) SetKeyValueSeparator(sep string) {
+	dec.kvSeparator = sep
+}
+
+// SetIntBase sets the base used to parse integer fields, as accepted by
+// strconv.ParseInt. Base 0 detects the base from a "0x", "0o" or "0b"
+// prefix. The "base" struct tag overrides it for a single field.
+// The default is 10.
+func (dec *Decoder) SetIntBase(base int) {
+	dec.intBase = base
+}
+
+// SetDecimalSeparator sets the character separating the integer and
+// fractional parts of float fields, so that with ',' "price=3,14" loads
+// as 3.14. Values are split by the slice delimiter first.
+// The default is '.'.
+func (dec *Decoder) SetDecimalSeparator(sep rune) {
+	dec.decimalSep = sep
+}
+
+// SetNullValue sets the form value that leaves a field unchanged,
+// as if its key were absent except that defaults are not applied and
+// required fields are satisfied. The default is "null"; some APIs use "".
+//
+// The null value is checked in this order:
+//   - An Unmarshaler receives it like any other value.
+//   - A scalar field, including one with a registered Converter, is left
+//     unchanged when its first value is the null value.
+//   - A slice or array field is left unchanged when the null value is its
+//     only value, before the value is split by the slice delimiter.
+//     Otherwise pointer elements holding the null value are set to nil.
+//   - A nested struct, map or struct slice field is left unchanged when
+//     its own key holds only the null value, and its prefixed keys are
+//     then ignored.
+func (dec *Decoder) SetNullValue(s string) {
+	dec.nullValue = s
+}
+
+// SetMaxBodySize sets the maximum number of bytes DecodeReader reads
+// before returning ErrBodyTooLarge. A size of 0 or less removes the limit.
+// The default is 10 MB.
+func (dec *Decoder) SetMaxBodySize(n int64) {
+	dec.maxBodySize = n
+}
+
+// SetMaxMemory sets the number of bytes of the file parts of a multipart
+// body that DecodeMultipart keeps in memory, writing the rest to temporary
+// files, as done by http.Request.ParseMultipartForm. The default is 32 MB.
+func (dec *Decoder) SetMaxMemory(n int64) {
+	dec.maxMemory = n
+}
+
+// SetMaxSliceLen sets the maximum number of elements loaded into a slice
+// field, including a slice of structs indexed as "items[n].name" and
+// the elements kept by SetSliceAppend. A field exceeding it is left
+// unchanged and a SliceLenError is reported.
+//
+// The limit is a safeguard against requests forcing large allocations,
+// such as a repeated key or a single "items[3000000000].qty" key, on public
+// endpoints. The default is 1000. A value of 0 or less removes the limit
+// for repeated values, whose number is bounded by the size of the request,
+// but a slice of structs still never grows past 1000 elements from its
+// indexes.
+func (dec *Decoder) SetMaxSliceLen(n int) {
+	dec.maxSliceLen = n
+}
+
+// SetNameMapper sets fn to derive the form key of a field without
+// a name in its tag from the Go field name, such as SnakeCase.
+// A name in the tag always takes precedence. By default the Go field
+// name is used as is.
+func (dec *Decoder) SetNameMapper(fn func(string) string) {
+	dec.nameMapper = fn
+}
+
+// SnakeCase converts a Go field name to snake case, so "UserID"
+// becomes "user_id" and "HTTPServer" becomes "http_server".
+// It is meant to be used with SetNameMapper.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	out := make([]rune, 0, len(runes)+4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				out = append(out, '_')
+			}
+		}
+		out = append(out, unicode.ToLower(r))
+	}
+	return string(out)
+}
+
+// RegisterConverter registers fn to convert form values into values of type t.
+// A registered converter takes precedence over every other conversion rule
+// of t, including Unmarshaler and encoding.TextUnmarshaler implementations.
+func (dec *Decoder) RegisterConverter(t reflect.Type, fn Converter) {
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.converters == nil {
+		dec.converters = make(map[reflect.Type]Converter)
+	}
+	dec.converters[t] = fn
+}
+
+// An interfaceImpl holds the concrete types registered for an interface.
+type interfaceImpl struct {
+	key   string                  // form key of the discriminator
+	types map[string]reflect.Type // concrete type by discriminator value
+}
+
+// RegisterInterfaceImpl registers the concrete types loaded into fields of
+// the interface type iface. The type is chosen by the value of the form key
+// discriminatorKey, looked up next to the field: with "type", impls
+// {"card": reflect.TypeOf(&CardPayment{})} and a field keyed "payment",
+// "type=card" loads the keys "payment.number" and so on into a new
+// *CardPayment. A concrete type that is not a struct or struct pointer is
+// loaded from the values of the field key itself.
+//
+// The field is left unchanged when discriminatorKey is absent, and a
+// LoadTypeError is reported for a value missing from impls.
+func (dec *Decoder) RegisterInterfaceImpl(iface reflect.Type, discriminatorKey string, impls map[string]reflect.Type) {
+	types := make(map[string]reflect.Type, len(impls))
+	for name, t := range impls {
+		types[name] = t
+	}
+
+	dec.mu.Lock()
+	defer dec.mu.Unlock()
+
+	if dec.impls == nil {
+		dec.impls = make(map[reflect.Type]interfaceImpl)
+	}
+	dec.impls[iface] = interfaceImpl{key: discriminatorKey, types: types}
+}
+
+// interfaceImpl returns the concrete types registered for interface t, if any.
+func (dec *Decoder) interfaceImpl(t reflect.Type) (interfaceImpl, bool) {
+	dec.mu.RLock()
+	defer dec.mu.RUnlock()
+
+	impl, ok := dec.impls[t]
+	return impl, ok
+}
+
+// converter returns the Converter registered for type t, if any.
+func (dec *Decoder) converter(t reflect.Type) (Converter, bool) {
+	dec.mu.RLock()
+	defer dec.mu.RUnlock()
+
+	conv, ok := dec.converters[t]
+	return conv, ok
+}
+
+// RegisterParseFunc registers fn, such as a package Parse function, as the
+// Converter of dec for the type T. Unlike with RegisterConverter, the type
+// is inferred from fn and its results need no conversion to reflect.Value.
+func RegisterParseFunc[T any](dec *Decoder, fn func(string) (T, error)) {
+	dec.RegisterConverter(reflect.TypeOf((*T)(nil)).Elem(), func(value string) (reflect.Value, error) {
+		v, err := fn(value)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&v).Elem(), nil
+	})
+}
+
+// Decode loads data into the struct pointed to by v.
+// A map field with the form key "_raw" receives a copy of all of data.
+//
+// Loading is best effort: a field whose values fail to load keeps its
+// prior value, and the other fields are loaded regardless, so that v holds
+// every valid field when an error is returned. A slice or array field is
+// replaced only once all of its elements load. The error describes the
+// first failing field, or all of them as LoadErrors with SetCollectErrors.
+func (dec *Decoder) Decode(data map[string][]string, v any) error {
+	return dec.DecodeContext(context.Background(), data, v)
+}
+
+// DecodeContext is like Decode but stops loading as soon as ctx is done,
+// returning ctx.Err(). Fields loaded before that keep their new values.
+func (dec *Decoder) DecodeContext(ctx context.Context, data map[string][]string, v any) error {
+	if data == nil {
+		return ErrNilData
+	}
+
+	d := dec.newDecodeState(ctx, data)
+	defer dec.freeDecodeState(d)
 	return d.parse(v)
 }
+
+// DecodeN is like Decode but also returns the number of fields loaded
+// from data without errors. Fields set from defaults or left unchanged
+// by the null value are not counted, and a field is counted once however
+// many values it received. The fields of a nested struct count one by one.
+func (dec *Decoder) DecodeN(data map[string][]string, v any) (int, error) {
+	if data == nil {
+		return 0, ErrNilData
+	}
+
+	d := dec.newDecodeState(context.Background(), data)
+	defer dec.freeDecodeState(d)
+	err := d.parse(v)
+	return d.populated, err
+}
+
+// DecodeReader reads URL-encoded form data from r, such as a request
+// body, and loads it into the struct pointed to by v. The data is read
+// in full before it is parsed, up to the size set with SetMaxBodySize.
+func (dec *Decoder) DecodeReader(r io.Reader, v any) error {
+	if dec.maxBodySize > 0 {
+		r = io.LimitReader(r, dec.maxBodySize+1)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if dec.maxBodySize > 0 && int64(len(b)) > dec.maxBodySize {
+		return ErrBodyTooLarge
+	}
+	data, err := url.ParseQuery(string(b))
+	if err != nil {
+		return err
+	}
+	return dec.Decode(data, v)
+}
+
+// DecodeValue loads raw, the form values of a single key, into target
+// following the same conversion rules as for struct fields.
+// target must be settable, such as the element of a pointer.
+func (dec *Decoder) DecodeValue(raw []string, target reflect.Value) error {
+	d := dec.newDecodeState(context.Background(), nil)
+	defer dec.freeDecodeState(d)
+	return d.parseValue(raw, target)
+}
+
+var defaultDecoder = NewDecoder()
+
+// Load loads data into the struct pointed to by v using the default Decoder.
+// It is safe to call Load from multiple goroutines.
+func Load(data map[string][]string, v any) error {
+	return defaultDecoder.Decode(data, v)
+}
+
+// LoadValues is like Load for url.Values, such as the Form or PostForm
+// of an http.Request or the result of url.ParseQuery. Since url.Values
+// is a map[string][]string, Load accepts it as well.
+func LoadValues(values url.Values, v any) error {
+	return defaultDecoder.Decode(values, v)
+}
+
+// LoadN is like Load but also returns the number of fields loaded,
+// as reported by Decoder.DecodeN.
+func LoadN(data map[string][]string, v any) (int, error) {
+	return defaultDecoder.DecodeN(data, v)
+}
+
+// Unmarshal parses query as a URL-encoded query string and loads it
+// into the struct pointed to by v using the default Decoder.
+func Unmarshal(query string, v any) error {
+	data, err := url.ParseQuery(query)
+	if err != nil {
+		return err
+	}
+	return Load(data, v)
+}
+
+// LoadReader reads URL-encoded form data from r and loads it into
+// the struct pointed to by v using the default Decoder.
+func LoadReader(r io.Reader, v any) error {
+	return defaultDecoder.DecodeReader(r, v)
+}
+
+// DecodeValue loads raw into target using the default Decoder.
+func DecodeValue(raw []string, target reflect.Value) error {
+	return defaultDecoder.DecodeValue(raw, target)
+}
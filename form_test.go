@@ -3,9 +3,21 @@ package form
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -38,3 +50,2725 @@ func TestParse_Successfully(t *testing.T) {
 	assert.Equal(t, obj.Type, "1")
 	assert.Equal(t, obj.Status, "success")
 }
+
+type testTimeObj struct {
+	CreatedAt time.Time  `request:"created_at"`
+	Day       time.Time  `request:"day" layout:"2006-01-02"`
+	UpdatedAt *time.Time `request:"updated_at"`
+}
+
+func TestLoad_TimeFields(t *testing.T) {
+	var obj testTimeObj
+	err := Load(map[string][]string{
+		"created_at": {"2023-01-02T15:04:05Z"},
+		"day":        {"2023-03-04"},
+		"updated_at": {"2023-05-06T07:08:09Z"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC), obj.CreatedAt)
+	assert.Equal(t, time.Date(2023, 3, 4, 0, 0, 0, 0, time.UTC), obj.Day)
+	if assert.NotNil(t, obj.UpdatedAt) {
+		assert.Equal(t, time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC), *obj.UpdatedAt)
+	}
+}
+
+func TestLoad_TimeFieldInvalid(t *testing.T) {
+	var obj testTimeObj
+	err := Load(map[string][]string{"day": {"04.03.2023"}}, &obj)
+
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.Equal(t, "time 04.03.2023", typeErr.Value)
+}
+
+type testTimeLayoutsObj struct {
+	Since time.Time `request:"since" layout:"2006-01-02|2006-01-02T15:04:05Z07:00"`
+}
+
+func TestLoad_TimeLayouts(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2023-03-04", want: time.Date(2023, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{value: "2023-03-04T05:06:07Z", want: time.Date(2023, 3, 4, 5, 6, 7, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var obj testTimeLayoutsObj
+			assert.NoError(t, Load(map[string][]string{"since": {tt.value}}, &obj))
+			assert.Equal(t, tt.want, obj.Since)
+		})
+	}
+
+	var obj testTimeLayoutsObj
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, Load(map[string][]string{"since": {"04.03.2023"}}, &obj), &typeErr) {
+		assert.Equal(t, "time 04.03.2023", typeErr.Value)
+	}
+}
+
+type testDurationObj struct {
+	Timeout time.Duration `request:"timeout"`
+}
+
+func TestLoad_DurationField(t *testing.T) {
+	var obj testDurationObj
+	err := Load(map[string][]string{"timeout": {"1h30m"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, obj.Timeout)
+
+	err = Load(map[string][]string{"timeout": {"soon"}}, &obj)
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.Equal(t, "duration soon", typeErr.Value)
+}
+
+type testNumbersObj struct {
+	Count uint    `request:"count"`
+	Price float64 `request:"price"`
+}
+
+func TestLoad_TypeErrorContext(t *testing.T) {
+	var obj testNumbersObj
+	err := Load(map[string][]string{"count": {"-5"}}, &obj)
+
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "testNumbersObj", typeErr.Struct)
+		assert.Equal(t, "count", typeErr.Field)
+		assert.Equal(t, reflect.TypeOf(uint(0)), typeErr.Type)
+	}
+	assert.EqualError(t, err, "form: cannot load number -5 into Go struct field testNumbersObj.count of type uint")
+}
+
+type testUintObj struct {
+	Count uint32  `request:"count"`
+	Limit *uint32 `request:"limit"`
+}
+
+func TestLoad_NegativeUint(t *testing.T) {
+	obj := testUintObj{Count: 7}
+	err := Load(map[string][]string{"count": {"-5"}, "limit": {"-5"}}, &obj)
+
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "count", typeErr.Field)
+		assert.Equal(t, "number -5", typeErr.Value)
+	}
+	assert.Equal(t, uint32(7), obj.Count)
+	assert.Nil(t, obj.Limit)
+}
+
+type testIntObj struct {
+	Age   int  `request:"age"`
+	Small int8 `request:"small"`
+}
+
+func TestLoad_IntFieldInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string][]string
+	}{
+		{name: "not a number", data: map[string][]string{"age": {"abc"}}},
+		{name: "overflow", data: map[string][]string{"small": {"300"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testIntObj
+			err := Load(tt.data, &obj)
+
+			var typeErr *LoadTypeError
+			assert.ErrorAs(t, err, &typeErr)
+			assert.Equal(t, testIntObj{}, obj)
+		})
+	}
+}
+
+type testBoolObj struct {
+	Active bool `request:"active"`
+	Name   string
+}
+
+func TestLoad_BoolField(t *testing.T) {
+	var obj testBoolObj
+	err := Load(map[string][]string{"active": {"true"}, "Name": {"bob"}}, &obj)
+	assert.NoError(t, err)
+
+	assert.True(t, obj.Active)
+	assert.Equal(t, "bob", obj.Name)
+}
+
+type testSliceObj struct {
+	Tags     []string        `request:"tags"`
+	IDs      []int           `request:"ids"`
+	Timeouts []time.Duration `request:"timeouts"`
+}
+
+func TestLoad_SliceFields(t *testing.T) {
+	var obj testSliceObj
+	err := Load(map[string][]string{
+		"tags":     {"a", "b"},
+		"ids":      {"1", "2", "3"},
+		"timeouts": {"1s", "1m"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, obj.Tags)
+	assert.Equal(t, []int{1, 2, 3}, obj.IDs)
+	assert.Equal(t, []time.Duration{time.Second, time.Minute}, obj.Timeouts)
+}
+
+type testPointerObj struct {
+	Age  *int    `request:"age"`
+	Name *string `request:"name"`
+	Note *string `request:"note"`
+}
+
+func TestLoad_PointerFields(t *testing.T) {
+	var obj testPointerObj
+	err := Load(map[string][]string{
+		"age":  {"0"},
+		"name": {"bob"},
+		"note": {"null"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	if assert.NotNil(t, obj.Age) {
+		assert.Equal(t, 0, *obj.Age)
+	}
+	if assert.NotNil(t, obj.Name) {
+		assert.Equal(t, "bob", *obj.Name)
+	}
+	assert.Nil(t, obj.Note)
+}
+
+func TestLoad_NullValue(t *testing.T) {
+	slices := testSliceObj{Tags: []string{"keep"}}
+	assert.NoError(t, Load(map[string][]string{"tags": {"null"}}, &slices))
+	assert.Equal(t, []string{"keep"}, slices.Tags)
+
+	nested := testNestedObj{Address: testAddress{City: "NYC"}}
+	assert.NoError(t, Load(map[string][]string{"address": {"null"}, "address.city": {"LA"}}, &nested))
+	assert.Equal(t, testAddress{City: "NYC"}, nested.Address)
+
+	maps := testMapObj{Limits: map[string]int{"users": 1}}
+	assert.NoError(t, Load(map[string][]string{"limit": {"null"}, "limit[users]": {"2"}}, &maps))
+	assert.Equal(t, map[string]int{"users": 1}, maps.Limits)
+
+	dec := NewDecoder()
+	dec.SetStrict(true)
+	assert.NoError(t, dec.Decode(map[string][]string{"address": {"null"}, "name": {"bob"}}, &nested))
+}
+
+func TestDecoder_SetNullValue(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetNullValue("")
+
+	obj := testPointerObj{}
+	err := dec.Decode(map[string][]string{"age": {""}, "name": {"null"}}, &obj)
+	assert.NoError(t, err)
+	assert.Nil(t, obj.Age)
+	if assert.NotNil(t, obj.Name) {
+		assert.Equal(t, "null", *obj.Name)
+	}
+
+	slices := testSliceObj{IDs: []int{7}}
+	assert.NoError(t, dec.Decode(map[string][]string{"ids": {""}}, &slices))
+	assert.Equal(t, []int{7}, slices.IDs)
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	dec := NewDecoder()
+
+	var obj testStatusObj
+	err := dec.Decode(map[string][]string{"type": {"1"}, "Status": {"ok"}}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testStatusObj{Status: "ok", Type: "1"}, obj)
+}
+
+type testSchemaObj struct {
+	UserID int `schema:"user_id"`
+	Name   string
+}
+
+func TestDecoder_SetTagName(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetTagName("schema")
+
+	var obj testSchemaObj
+	err := dec.Decode(map[string][]string{"user_id": {"7"}, "Name": {"bob"}}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testSchemaObj{UserID: 7, Name: "bob"}, obj)
+}
+
+func TestDecoder_SetSliceDelimiter(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetSliceDelimiter(",")
+
+	tests := []struct {
+		name string
+		data map[string][]string
+		want []int
+	}{
+		{name: "single joined value", data: map[string][]string{"ids": {"1,2,3"}}, want: []int{1, 2, 3}},
+		{name: "repeated keys", data: map[string][]string{"ids": {"1", "2"}}, want: []int{1, 2}},
+		{name: "trailing delimiter", data: map[string][]string{"ids": {"1,2,"}}, want: []int{1, 2}},
+		{name: "empty value", data: map[string][]string{"ids": {""}}, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testSliceObj
+			err := dec.Decode(tt.data, &obj)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, obj.IDs)
+		})
+	}
+}
+
+type testSkipObj struct {
+	Login        string `request:"login"`
+	PasswordHash string `request:"-"`
+	Role         string `request:"-"`
+}
+
+func TestLoad_SkipField(t *testing.T) {
+	obj := testSkipObj{Role: "user"}
+	err := Load(map[string][]string{
+		"login":        {"bob"},
+		"PasswordHash": {"hash"},
+		"-":            {"admin"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testSkipObj{Login: "bob", Role: "user"}, obj)
+}
+
+type testRequiredObj struct {
+	UserID int    `request:"user_id,required"`
+	Name   string `request:",required"`
+	Note   string `request:"note"`
+}
+
+func TestLoad_RequiredField(t *testing.T) {
+	var obj testRequiredObj
+	err := Load(map[string][]string{"user_id": {"7"}, "Name": {"bob"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testRequiredObj{UserID: 7, Name: "bob"}, obj)
+
+	err = Load(map[string][]string{"Name": {"bob"}}, &obj)
+	var missingErr *MissingFieldError
+	if assert.ErrorAs(t, err, &missingErr) {
+		assert.Equal(t, "user_id", missingErr.Field)
+	}
+	assert.EqualError(t, err, "form: missing required field user_id")
+}
+
+type testDefaultObj struct {
+	Page    int           `request:"page" default:"1"`
+	Sort    string        `request:"sort" default:"id"`
+	Timeout time.Duration `request:"timeout" default:"5s"`
+	Limit   int           `request:"limit"`
+}
+
+func TestLoad_DefaultValues(t *testing.T) {
+	var obj testDefaultObj
+	err := Load(map[string][]string{"sort": {"name"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testDefaultObj{Page: 1, Sort: "name", Timeout: 5 * time.Second}, obj)
+
+	obj = testDefaultObj{}
+	err = Load(map[string][]string{"sort": {""}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "", obj.Sort)
+}
+
+func TestDecoder_SetCollectErrors(t *testing.T) {
+	data := map[string][]string{
+		"age":   {"abc"},
+		"small": {"300"},
+	}
+
+	var obj testIntObj
+	err := Load(data, &obj)
+	var loadErrs LoadErrors
+	assert.False(t, errors.As(err, &loadErrs))
+
+	dec := NewDecoder()
+	dec.SetCollectErrors(true)
+	err = dec.Decode(data, &obj)
+	if assert.ErrorAs(t, err, &loadErrs) {
+		assert.Len(t, loadErrs, 2)
+	}
+
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, err, &typeErr)
+}
+
+func TestLoadErrors_ToMap(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetCollectErrors(true)
+	dec.SetStrict(true)
+
+	var obj testRequiredObj
+	err := dec.Decode(map[string][]string{
+		"user_id": {"abc", "def"},
+		"extra":   {"1"},
+	}, &obj)
+
+	var loadErrs LoadErrors
+	if assert.ErrorAs(t, err, &loadErrs) {
+		assert.Equal(t, map[string]string{
+			"user_id": "invalid number abc",
+			"Name":    "required",
+			"extra":   "unknown field",
+		}, loadErrs.ToMap())
+	}
+
+	errs := LoadErrors{errors.New("form: boom"), &MissingFieldError{Field: "a"}, &MissingFieldError{Field: "a"}}
+	b, err := json.Marshal(errs.ToMap())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"":"form: boom","a":"required"}`, string(b))
+}
+
+type testAddress struct {
+	City string `request:"city"`
+	Zip  int    `request:"zip"`
+}
+
+type testNestedObj struct {
+	Name    string      `request:"name"`
+	Address testAddress `request:"address"`
+}
+
+func TestLoad_NestedStruct(t *testing.T) {
+	var obj testNestedObj
+	err := Load(map[string][]string{
+		"name":         {"bob"},
+		"address.city": {"NYC"},
+		"address.zip":  {"10001"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testNestedObj{Name: "bob", Address: testAddress{City: "NYC", Zip: 10001}}, obj)
+
+	err = Load(map[string][]string{"address.zip": {"abc"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "testAddress", typeErr.Struct)
+		assert.Equal(t, "address.zip", typeErr.Field)
+	}
+}
+
+func TestDecoder_SetNestedSeparator(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetNestedSeparator("_")
+
+	var obj testNestedObj
+	err := dec.Decode(map[string][]string{"address_city": {"NYC"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "NYC", obj.Address.City)
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type testTextObj struct {
+	Level    testLevel  `request:"level"`
+	Priority *testLevel `request:"priority"`
+}
+
+func TestLoad_TextUnmarshaler(t *testing.T) {
+	var obj testTextObj
+	err := Load(map[string][]string{"level": {"high"}, "priority": {"low"}}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testLevel(2), obj.Level)
+	if assert.NotNil(t, obj.Priority) {
+		assert.Equal(t, testLevel(1), *obj.Priority)
+	}
+
+	err = Load(map[string][]string{"level": {"medium"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "level", typeErr.Field)
+	}
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func (p *testPoint) UnmarshalForm(values []string) error {
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	if len(values) != 2 {
+		return errors.New("point needs two coordinates")
+	}
+
+	var err error
+	if p.X, err = strconv.Atoi(values[0]); err != nil {
+		return err
+	}
+	p.Y, err = strconv.Atoi(values[1])
+	return err
+}
+
+type testPointObj struct {
+	From testPoint  `request:"from"`
+	To   *testPoint `request:"to"`
+}
+
+func TestLoad_Unmarshaler(t *testing.T) {
+	var obj testPointObj
+	err := Load(map[string][]string{"from": {"1,2"}, "to": {"3", "4"}}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testPoint{X: 1, Y: 2}, obj.From)
+	if assert.NotNil(t, obj.To) {
+		assert.Equal(t, testPoint{X: 3, Y: 4}, *obj.To)
+	}
+
+	err = Load(map[string][]string{"from": {"1"}}, &obj)
+	assert.EqualError(t, err, "point needs two coordinates")
+
+	obj = testPointObj{}
+	err = Load(map[string][]string{"to": {"3"}}, &obj)
+	assert.EqualError(t, err, "point needs two coordinates")
+	assert.Nil(t, obj.To)
+}
+
+type testMapObj struct {
+	Filter map[string]string `request:"filter"`
+	Limits map[string]int    `request:"limit"`
+	Extra  map[string]string `request:"extra"`
+}
+
+func TestLoad_MapFields(t *testing.T) {
+	var obj testMapObj
+	err := Load(map[string][]string{
+		"filter[status]": {"active"},
+		"filter[role]":   {"admin"},
+		"limit[users]":   {"10"},
+		"filter":         {"ignored"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"status": "active", "role": "admin"}, obj.Filter)
+	assert.Equal(t, map[string]int{"users": 10}, obj.Limits)
+	assert.Nil(t, obj.Extra)
+
+	err = Load(map[string][]string{"limit[users]": {"many"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "limit[users]", typeErr.Field)
+	}
+}
+
+type testRawObj struct {
+	Tags map[string][]string `request:"tag"`
+	Raw  map[string][]string `request:"_raw"`
+	Name string              `request:"name"`
+}
+
+func TestLoad_MapOfSliceFields(t *testing.T) {
+	data := map[string][]string{
+		"tag[color]": {"red", "blue"},
+		"name":       {"bob"},
+	}
+
+	var obj testRawObj
+	assert.NoError(t, Load(data, &obj))
+
+	assert.Equal(t, map[string][]string{"color": {"red", "blue"}}, obj.Tags)
+	assert.Equal(t, data, obj.Raw)
+	assert.Equal(t, "bob", obj.Name)
+
+	obj.Raw["name"][0] = "alice"
+	obj.Tags["color"][0] = "green"
+	assert.Equal(t, []string{"bob"}, data["name"])
+	assert.Equal(t, []string{"red", "blue"}, data["tag[color]"])
+}
+
+func TestLoad_RawFieldKeepsOriginalKeys(t *testing.T) {
+	data := map[string][]string{
+		"Tag[Color]": {" red "},
+		"NAME":       {"bob"},
+	}
+
+	dec := NewDecoder()
+	dec.SetCaseInsensitive(true)
+	dec.SetTrimSpace(true)
+
+	var obj testRawObj
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, "bob", obj.Name)
+	assert.Equal(t, data, obj.Raw)
+
+	var m map[string]string
+	assert.NoError(t, dec.Decode(data, &m))
+	assert.Equal(t, map[string]string{"Tag[Color]": " red ", "NAME": "bob"}, m)
+}
+
+type testCaseObj struct {
+	UserID int    `request:"userId"`
+	Name   string `request:"name"`
+}
+
+func TestDecoder_SetCaseInsensitive(t *testing.T) {
+	data := map[string][]string{"USERID": {"7"}, "Name": {"bob"}, "name": {"alice"}}
+
+	var obj testCaseObj
+	assert.NoError(t, Load(data, &obj))
+	assert.Equal(t, testCaseObj{Name: "alice"}, obj)
+
+	dec := NewDecoder()
+	dec.SetCaseInsensitive(true)
+
+	obj = testCaseObj{}
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, testCaseObj{UserID: 7, Name: "bob"}, obj)
+
+	var raw testRawObj
+	assert.NoError(t, dec.Decode(map[string][]string{"Tag[UserName]": {"x"}}, &raw))
+	assert.Equal(t, map[string][]string{"UserName": {"x"}}, raw.Tags)
+
+	var order testOrderObj
+	assert.NoError(t, dec.Decode(map[string][]string{"Items[0].Name": {"a"}}, &order))
+	assert.Equal(t, []testItem{{Name: "a"}}, order.Items)
+
+	var sections testSectionsObj
+	assert.NoError(t, dec.Decode(map[string][]string{"Sections[General][Title]": {"G"}}, &sections))
+	assert.Equal(t, map[string]testSection{"General": {Title: "G"}}, sections.Sections)
+}
+
+func TestDecoder_SetStrict(t *testing.T) {
+	data := map[string][]string{
+		"user_id":      {"7"},
+		"Name":         {"bob"},
+		"usr_id":       {"8"},
+		"address.city": {"NYC"},
+	}
+
+	var obj testRequiredObj
+	assert.NoError(t, Load(data, &obj))
+
+	dec := NewDecoder()
+	dec.SetStrict(true)
+	err := dec.Decode(data, &obj)
+
+	var unknownErr *UnknownFieldError
+	if assert.ErrorAs(t, err, &unknownErr) {
+		assert.Equal(t, "address.city", unknownErr.Key)
+	}
+
+	dec.SetCollectErrors(true)
+	err = dec.Decode(data, &obj)
+	var loadErrs LoadErrors
+	if assert.ErrorAs(t, err, &loadErrs) {
+		assert.Equal(t, LoadErrors{
+			&UnknownFieldError{Key: "address.city"},
+			&UnknownFieldError{Key: "usr_id"},
+		}, loadErrs)
+	}
+
+	var raw testRawObj
+	assert.NoError(t, dec.Decode(map[string][]string{"name": {"bob"}, "other": {"1"}}, &raw))
+	assert.Equal(t, map[string][]string{"name": {"bob"}, "other": {"1"}}, raw.Raw)
+}
+
+type testBenchObj struct {
+	ID      int       `request:"id"`
+	Name    string    `request:"name"`
+	Email   string    `request:"email"`
+	Active  bool      `request:"active"`
+	Score   float64   `request:"score"`
+	Tags    []string  `request:"tags"`
+	Created time.Time `request:"created"`
+	Page    int       `request:"page" default:"1"`
+}
+
+func BenchmarkLoad(b *testing.B) {
+	data := map[string][]string{
+		"id":      {"42"},
+		"name":    {"bob"},
+		"email":   {"bob@example.com"},
+		"active":  {"true"},
+		"score":   {"9.5"},
+		"tags":    {"a", "b", "c"},
+		"created": {"2023-01-02T15:04:05Z"},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var obj testBenchObj
+		if err := Load(data, &obj); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoad_Parallel(b *testing.B) {
+	data := map[string][]string{
+		"id":     {"42"},
+		"name":   {"bob"},
+		"active": {"true"},
+		"tags":   {"a", "b", "c"},
+	}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var obj testBenchObj
+			if err := Load(data, &obj); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+type testPagination struct {
+	Page  int `request:"page"`
+	Limit int `request:"limit"`
+}
+
+type testEmbeddedObj struct {
+	testPagination
+	Limit string `request:"limit"`
+	Query string `request:"q"`
+}
+
+func TestLoad_EmbeddedStruct(t *testing.T) {
+	var obj testEmbeddedObj
+	err := Load(map[string][]string{
+		"page":  {"2"},
+		"limit": {"all"},
+		"q":     {"go"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, obj.Page)
+	assert.Equal(t, 0, obj.testPagination.Limit)
+	assert.Equal(t, "all", obj.Limit)
+	assert.Equal(t, "go", obj.Query)
+}
+
+func TestLoad_BoolSynonyms(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{value: "on", want: true},
+		{value: "YES", want: true},
+		{value: "y", want: true},
+		{value: "1", want: true},
+		{value: "Off", want: false},
+		{value: "no", want: false},
+		{value: "n", want: false},
+		{value: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			obj := testBoolObj{Active: !tt.want}
+			err := Load(map[string][]string{"active": {tt.value}}, &obj)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, obj.Active)
+		})
+	}
+
+	var obj testBoolObj
+	err := Load(map[string][]string{"active": {"maybe"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "bool maybe", typeErr.Value)
+	}
+}
+
+type testInterfaceObj struct {
+	One   any          `request:"one"`
+	Many  interface{}  `request:"many"`
+	Typed fmt.Stringer `request:"typed"`
+}
+
+func TestLoad_InterfaceFields(t *testing.T) {
+	var obj testInterfaceObj
+	err := Load(map[string][]string{
+		"one":  {"a"},
+		"many": {"a", "b"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "a", obj.One)
+	assert.Equal(t, []string{"a", "b"}, obj.Many)
+
+	err = Load(map[string][]string{"typed": {"a"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "typed", typeErr.Field)
+	}
+	assert.Nil(t, obj.Typed)
+}
+
+type testBytesObj struct {
+	Signature []byte `request:"sig"`
+	Nonce     []byte `request:"nonce" encoding:"hex"`
+}
+
+func TestLoad_BytesFields(t *testing.T) {
+	var obj testBytesObj
+	err := Load(map[string][]string{
+		"sig":   {"aGVsbG8="},
+		"nonce": {"cafe"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []byte("hello"), obj.Signature)
+	assert.Equal(t, []byte{0xca, 0xfe}, obj.Nonce)
+
+	err = Load(map[string][]string{"nonce": {"zz"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "nonce", typeErr.Field)
+	}
+}
+
+func TestLoadValues(t *testing.T) {
+	values := url.Values{}
+	values.Set("type", "1")
+	values.Add("Status", "ok")
+
+	var obj testStatusObj
+	assert.NoError(t, LoadValues(values, &obj))
+	assert.Equal(t, testStatusObj{Status: "ok", Type: "1"}, obj)
+
+	assert.ErrorIs(t, LoadValues(nil, &obj), ErrNilData)
+}
+
+func TestUnmarshal(t *testing.T) {
+	var obj testSliceObj
+	err := Unmarshal("tags=a&tags=b&ids=1", &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testSliceObj{Tags: []string{"a", "b"}, IDs: []int{1}}, obj)
+
+	err = Unmarshal("tags=%zz", &obj)
+	assert.Error(t, err)
+}
+
+func TestLoadReader(t *testing.T) {
+	var obj testSliceObj
+	err := LoadReader(strings.NewReader("tags=a&tags=b&ids=1&ids=2"), &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testSliceObj{Tags: []string{"a", "b"}, IDs: []int{1, 2}}, obj)
+
+	assert.Error(t, LoadReader(strings.NewReader("tags=%zz"), &obj))
+
+	dec := NewDecoder()
+	dec.SetMaxBodySize(8)
+	assert.NoError(t, dec.DecodeReader(strings.NewReader("tags=abc"), &obj))
+	assert.ErrorIs(t, dec.DecodeReader(strings.NewReader("tags=abcd"), &obj), ErrBodyTooLarge)
+
+	dec.SetMaxBodySize(0)
+	assert.NoError(t, dec.DecodeReader(strings.NewReader("tags="+strings.Repeat("a", 64)), &obj))
+}
+
+type testItem struct {
+	Name string `request:"name"`
+	Qty  int    `request:"qty"`
+}
+
+type testOrderObj struct {
+	Items []testItem `request:"items"`
+}
+
+func TestLoad_IndexedStructSlice(t *testing.T) {
+	var obj testOrderObj
+	err := Load(map[string][]string{
+		"items[0].name": {"a"},
+		"items[0].qty":  {"2"},
+		"items[2].name": {"c"},
+		"items[x].name": {"ignored"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []testItem{{Name: "a", Qty: 2}, {}, {Name: "c"}}, obj.Items)
+
+	err = Load(map[string][]string{"items[1].qty": {"many"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "items[1].qty", typeErr.Field)
+	}
+
+	obj = testOrderObj{}
+	err = Load(map[string][]string{"items[3000000000].qty": {"1"}}, &obj)
+	var lenErr *SliceLenError
+	if assert.ErrorAs(t, err, &lenErr) {
+		assert.Equal(t, "items", lenErr.Field)
+		assert.Equal(t, 1000, lenErr.Max)
+	}
+	assert.Nil(t, obj.Items)
+
+	obj = testOrderObj{}
+	err = Load(map[string][]string{"items[9223372036854775807].qty": {"1"}}, &obj)
+	assert.ErrorAs(t, err, &lenErr)
+	assert.Nil(t, obj.Items)
+
+	dec := NewDecoder()
+	dec.SetStrict(true)
+	dec.SetCollectErrors(true)
+	err = dec.Decode(map[string][]string{
+		"items[0].qty":    {"1"},
+		"items[5000].qty": {"1"},
+	}, &obj)
+	assert.ErrorAs(t, err, &lenErr)
+	var unknownErr *UnknownFieldError
+	assert.False(t, errors.As(err, &unknownErr))
+
+	assert.NoError(t, Load(map[string][]string{"items[999].qty": {"1"}}, &obj))
+	assert.Len(t, obj.Items, 1000)
+}
+
+type testShipmentObj struct {
+	Orders []testOrderObj `request:"orders"`
+	Weight [2]int         `request:"weight"`
+}
+
+func TestLoad_NestedErrorPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   map[string][]string
+		strukt string
+		field  string
+	}{
+		{name: "nested slices", data: map[string][]string{"orders[2].items[0].qty": {"x"}}, strukt: "testItem", field: "orders[2].items[0].qty"},
+		{name: "array element", data: map[string][]string{"weight": {"1", "x"}}, strukt: "testShipmentObj", field: "weight[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testShipmentObj
+			err := Load(tt.data, &obj)
+			var typeErr *LoadTypeError
+			if assert.ErrorAs(t, err, &typeErr) {
+				assert.Equal(t, tt.strukt, typeErr.Struct)
+				assert.Equal(t, tt.field, typeErr.Field)
+			}
+		})
+	}
+
+	dec := NewDecoder()
+	dec.SetNestedSeparator("_")
+	var obj testShipmentObj
+	err := dec.Decode(map[string][]string{"orders[0]_items[1]_qty": {"x"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "orders[0]_items[1]_qty", typeErr.Field)
+		assert.EqualError(t, err, "form: cannot load number x into Go struct field testItem.orders[0]_items[1]_qty of type int")
+	}
+}
+
+type testAliasObj struct {
+	UserID int `request:"user_id,uid,required"`
+}
+
+func TestLoad_AliasNames(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string][]string
+		want int
+	}{
+		{name: "primary", data: map[string][]string{"user_id": {"1"}}, want: 1},
+		{name: "alias", data: map[string][]string{"uid": {"2"}}, want: 2},
+		{name: "primary wins", data: map[string][]string{"user_id": {"1"}, "uid": {"2"}}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testAliasObj
+			err := Load(tt.data, &obj)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, obj.UserID)
+		})
+	}
+
+	var obj testAliasObj
+	err := Load(map[string][]string{}, &obj)
+	assert.EqualError(t, err, "form: missing required field user_id")
+}
+
+type testUnsupportedObj struct {
+	Events chan int         `request:"events"`
+	Hooks  []func()         `request:"hooks"`
+	Groups map[string][]int `request:"groups"`
+}
+
+func TestLoad_UnsupportedKinds(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  map[string][]string
+		field string
+	}{
+		{name: "chan", data: map[string][]string{"events": {"1"}}, field: "events"},
+		{name: "slice of func", data: map[string][]string{"hooks": {"a"}}, field: "hooks[0]"},
+		{name: "map of slices", data: map[string][]string{"groups[a]": {"1"}}, field: "groups[a]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testUnsupportedObj
+			err := Load(tt.data, &obj)
+
+			var typeErr *LoadTypeError
+			if assert.ErrorAs(t, err, &typeErr) {
+				assert.Equal(t, "testUnsupportedObj", typeErr.Struct)
+				assert.Equal(t, tt.field, typeErr.Field)
+			}
+		})
+	}
+}
+
+type testArrayObj struct {
+	Coords  [2]float64 `request:"coords"`
+	Version [3]int     `request:"version"`
+}
+
+func TestLoad_ArrayFields(t *testing.T) {
+	var obj testArrayObj
+	err := Load(map[string][]string{
+		"coords":  {"1.5", "2.5", "3.5"},
+		"version": {"1", "2"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testArrayObj{Coords: [2]float64{1.5, 2.5}, Version: [3]int{1, 2, 0}}, obj)
+
+	dec := NewDecoder()
+	dec.SetStrict(true)
+	err = dec.Decode(map[string][]string{"coords": {"1.5", "2.5", "3.5"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "coords", typeErr.Field)
+	}
+}
+
+type testMoney struct {
+	Cents int64
+}
+
+type testMoneyObj struct {
+	Price  testMoney   `request:"price"`
+	Prices []testMoney `request:"prices"`
+}
+
+func parseTestMoney(s string) (reflect.Value, error) {
+	units, cents, _ := strings.Cut(s, ".")
+	u, err := strconv.ParseInt(units, 10, 64)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	c, err := strconv.ParseInt(cents, 10, 64)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(testMoney{Cents: u*100 + c}), nil
+}
+
+func TestDecoder_RegisterConverter(t *testing.T) {
+	dec := NewDecoder()
+	dec.RegisterConverter(reflect.TypeOf(testMoney{}), parseTestMoney)
+
+	var obj testMoneyObj
+	err := dec.Decode(map[string][]string{
+		"price":  {"12.34"},
+		"prices": {"1.05", "0.99"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testMoneyObj{
+		Price:  testMoney{Cents: 1234},
+		Prices: []testMoney{{Cents: 105}, {Cents: 99}},
+	}, obj)
+
+	err = dec.Decode(map[string][]string{"price": {"free"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "price", typeErr.Field)
+	}
+}
+
+type testWidthObj struct {
+	Small  int8    `request:"small"`
+	Byte   uint8   `request:"byte"`
+	Shorts []int16 `request:"shorts"`
+}
+
+func TestLoad_IntegerWidthOverflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  map[string][]string
+		field string
+	}{
+		{name: "int8", data: map[string][]string{"small": {"300"}}, field: "small"},
+		{name: "uint8", data: map[string][]string{"byte": {"256"}}, field: "byte"},
+		{name: "int16 slice", data: map[string][]string{"shorts": {"1", "40000"}}, field: "shorts[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testWidthObj
+			err := Load(tt.data, &obj)
+
+			var typeErr *LoadTypeError
+			if assert.ErrorAs(t, err, &typeErr) {
+				assert.Equal(t, tt.field, typeErr.Field)
+			}
+			assert.Zero(t, obj.Small)
+			assert.Zero(t, obj.Byte)
+		})
+	}
+
+	var obj testWidthObj
+	assert.NoError(t, Load(map[string][]string{"small": {"-128"}, "byte": {"255"}}, &obj))
+	assert.Equal(t, testWidthObj{Small: -128, Byte: 255}, obj)
+}
+
+func TestDecoder_SetSkipEmpty(t *testing.T) {
+	data := map[string][]string{"age": {""}, "name": {""}}
+
+	obj := testPointerObj{Age: new(int), Name: new(string)}
+	*obj.Age = 30
+	*obj.Name = "bob"
+	err := Load(data, &obj)
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.Equal(t, "", *obj.Name)
+
+	dec := NewDecoder()
+	dec.SetSkipEmpty(true)
+
+	*obj.Name = "bob"
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, 30, *obj.Age)
+	assert.Equal(t, "bob", *obj.Name)
+}
+
+func TestDecoder_DecodeContext(t *testing.T) {
+	dec := NewDecoder()
+	data := map[string][]string{"type": {"1"}}
+
+	var obj testStatusObj
+	assert.NoError(t, dec.DecodeContext(context.Background(), data, &obj))
+	assert.Equal(t, "1", obj.Type)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	obj = testStatusObj{}
+	err := dec.DecodeContext(ctx, data, &obj)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, testStatusObj{}, obj)
+}
+
+type testJSONNumberObj struct {
+	Amount json.Number `request:"amount"`
+}
+
+func TestLoad_JSONNumber(t *testing.T) {
+	for _, value := range []string{"0", "-12", "3.14", "1e-3", "2E+10"} {
+		var obj testJSONNumberObj
+		assert.NoError(t, Load(map[string][]string{"amount": {value}}, &obj), value)
+		assert.Equal(t, json.Number(value), obj.Amount)
+	}
+
+	for _, value := range []string{"", "abc", "01", "1.", "+1", "0x10", "NaN"} {
+		var obj testJSONNumberObj
+		err := Load(map[string][]string{"amount": {value}}, &obj)
+		var typeErr *LoadTypeError
+		assert.ErrorAs(t, err, &typeErr, value)
+		assert.Equal(t, json.Number(""), obj.Amount)
+	}
+}
+
+func TestLoad_TopLevelMap(t *testing.T) {
+	data := map[string][]string{"a": {"1", "2"}, "b": {"3"}}
+
+	var values map[string][]string
+	assert.NoError(t, Load(data, &values))
+	assert.Equal(t, data, values)
+	values["a"][0] = "changed"
+	assert.Equal(t, "1", data["a"][0])
+
+	var joined map[string]string
+	assert.NoError(t, Load(data, &joined))
+	assert.Equal(t, map[string]string{"a": "1,2", "b": "3"}, joined)
+
+	var numbers map[string]int
+	assert.NoError(t, Load(data, &numbers))
+	assert.Equal(t, map[string]int{"a": 1, "b": 3}, numbers)
+}
+
+type testComplexObj struct {
+	Z     complex128   `request:"z"`
+	Small complex64    `request:"small"`
+	Roots []complex128 `request:"roots"`
+}
+
+func TestLoad_ComplexFields(t *testing.T) {
+	var obj testComplexObj
+	err := Load(map[string][]string{
+		"z":     {"(1+2i)"},
+		"small": {"3-4i"},
+		"roots": {"1", "-1i"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testComplexObj{Z: 1 + 2i, Small: 3 - 4i, Roots: []complex128{1, -1i}}, obj)
+
+	err = Load(map[string][]string{"z": {"1+"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "number 1+", typeErr.Value)
+	}
+}
+
+type testBaseObj struct {
+	Mask  uint32 `request:"mask" base:"0"`
+	Flags int    `request:"flags"`
+}
+
+func TestLoad_IntBase(t *testing.T) {
+	var obj testBaseObj
+	err := Load(map[string][]string{"mask": {"0xFF"}, "flags": {"10"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testBaseObj{Mask: 0xFF, Flags: 10}, obj)
+
+	err = Load(map[string][]string{"flags": {"0b101"}}, &obj)
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, err, &typeErr)
+
+	dec := NewDecoder()
+	dec.SetIntBase(0)
+	obj = testBaseObj{}
+	err = dec.Decode(map[string][]string{"mask": {"0o17"}, "flags": {"0b101"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testBaseObj{Mask: 0o17, Flags: 0b101}, obj)
+}
+
+type testSliceKindsObj struct {
+	Flags  []bool      `request:"flags"`
+	Points []complex64 `request:"points"`
+	Limits []*int      `request:"limits"`
+}
+
+func TestLoad_SliceElementKinds(t *testing.T) {
+	var obj testSliceKindsObj
+	err := Load(map[string][]string{
+		"flags":  {"true", "false", "on"},
+		"points": {"1+1i", "2"},
+		"limits": {"5", "null"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []bool{true, false, true}, obj.Flags)
+	assert.Equal(t, []complex64{1 + 1i, 2}, obj.Points)
+	if assert.Len(t, obj.Limits, 2) {
+		assert.Equal(t, 5, *obj.Limits[0])
+		assert.Nil(t, obj.Limits[1])
+	}
+
+	err = Load(map[string][]string{"flags": {"true", "maybe"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "array maybe", typeErr.Value)
+		assert.Equal(t, "flags[1]", typeErr.Field)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	var n int
+	assert.NoError(t, DecodeValue([]string{"42"}, reflect.ValueOf(&n).Elem()))
+	assert.Equal(t, 42, n)
+
+	var ids []uint
+	assert.NoError(t, DecodeValue([]string{"1", "2"}, reflect.ValueOf(&ids).Elem()))
+	assert.Equal(t, []uint{1, 2}, ids)
+
+	var d time.Duration
+	err := DecodeValue([]string{"soon"}, reflect.ValueOf(&d).Elem())
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "form: cannot load duration soon into Go value of type time.Duration", typeErr.Error())
+	}
+
+	var invalidErr *InvalidLoadError
+	assert.ErrorAs(t, DecodeValue([]string{"1"}, reflect.ValueOf(n)), &invalidErr)
+	assert.EqualError(t, DecodeValue([]string{"1"}, reflect.Value{}), "form: Parse(nil)")
+}
+
+type testNetObj struct {
+	IP    net.IP     `request:"ip"`
+	CIDR  net.IPNet  `request:"cidr"`
+	Allow *net.IPNet `request:"allow"`
+}
+
+func TestLoad_NetFields(t *testing.T) {
+	var obj testNetObj
+	err := Load(map[string][]string{
+		"ip":    {"192.168.1.1"},
+		"cidr":  {"10.0.0.0/8"},
+		"allow": {"2001:db8::/32"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "192.168.1.1", obj.IP.String())
+	assert.Equal(t, "10.0.0.0/8", obj.CIDR.String())
+	if assert.NotNil(t, obj.Allow) {
+		assert.Equal(t, "2001:db8::/32", obj.Allow.String())
+	}
+
+	for _, data := range []map[string][]string{
+		{"ip": {"300.1.1.1"}},
+		{"cidr": {"10.0.0.0"}},
+	} {
+		err = Load(data, &obj)
+		var typeErr *LoadTypeError
+		assert.ErrorAs(t, err, &typeErr)
+	}
+}
+
+func TestLoad_Concurrent(t *testing.T) {
+	dec := NewDecoder()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if i%10 == 0 {
+				dec.RegisterConverter(reflect.TypeOf(testMoney{}), parseTestMoney)
+			}
+
+			var obj testNestedObj
+			id := strconv.Itoa(i)
+			err := dec.Decode(map[string][]string{
+				"name":        {id},
+				"address.zip": {id},
+			}, &obj)
+			assert.NoError(t, err)
+			assert.Equal(t, testNestedObj{Name: id, Address: testAddress{Zip: i}}, obj)
+
+			var order testOrderObj
+			assert.NoError(t, Load(map[string][]string{"items[0].qty": {id}}, &order))
+			assert.Equal(t, []testItem{{Qty: i}}, order.Items)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDecoder_ConcurrentErrors(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetCollectErrors(true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := strconv.Itoa(i)
+			var obj testIntObj
+			err := dec.Decode(map[string][]string{"age": {"x" + id}, "small": {"1" + id + "000"}}, &obj)
+
+			var errs LoadErrors
+			if assert.ErrorAs(t, err, &errs) && assert.Len(t, errs, 2) {
+				var typeErr *LoadTypeError
+				assert.ErrorAs(t, errs[0], &typeErr)
+				assert.Equal(t, "number x"+id, typeErr.Value)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// PageParams is exported because nil pointers to unexported embedded
+// structs cannot be allocated.
+type PageParams struct {
+	Page int `request:"page"`
+}
+
+type testEmbeddedPointerObj struct {
+	*PageParams
+	Query string `request:"q"`
+}
+
+func TestLoad_EmbeddedPointerStruct(t *testing.T) {
+	var obj testEmbeddedPointerObj
+	err := Load(map[string][]string{"page": {"3"}}, &obj)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj.PageParams) {
+		assert.Equal(t, 3, obj.Page)
+	}
+
+	obj = testEmbeddedPointerObj{}
+	err = Load(map[string][]string{"q": {"go"}}, &obj)
+	assert.NoError(t, err)
+	assert.Nil(t, obj.PageParams)
+	assert.Equal(t, "go", obj.Query)
+
+	values, err := Encode(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"q": {"go"}}, values)
+}
+
+func TestDecoder_SetTrimSpace(t *testing.T) {
+	data := map[string][]string{"ids": {" 42 ", "\t7"}, "tags": {" a "}}
+
+	var obj testSliceObj
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, Load(data, &obj), &typeErr)
+
+	dec := NewDecoder()
+	dec.SetTrimSpace(true)
+
+	obj = testSliceObj{}
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, testSliceObj{IDs: []int{42, 7}, Tags: []string{"a"}}, obj)
+	assert.Equal(t, " 42 ", data["ids"][0])
+
+	var n int
+	assert.NoError(t, dec.DecodeValue([]string{" 5 "}, reflect.ValueOf(&n).Elem()))
+	assert.Equal(t, 5, n)
+}
+
+func TestLoad_NilData(t *testing.T) {
+	var obj testStatusObj
+	assert.ErrorIs(t, Load(nil, &obj), ErrNilData)
+
+	var values url.Values
+	assert.ErrorIs(t, Load(values, &obj), ErrNilData)
+
+	obj = testStatusObj{}
+	assert.NoError(t, Load(map[string][]string{}, &obj))
+	assert.Equal(t, testStatusObj{}, obj)
+}
+
+type testPriceObj struct {
+	Price  float64   `request:"price"`
+	Prices []float32 `request:"prices"`
+}
+
+func TestDecoder_SetDecimalSeparator(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetDecimalSeparator(',')
+	dec.SetSliceDelimiter(";")
+
+	var obj testPriceObj
+	err := dec.Decode(map[string][]string{"price": {"3,14"}, "prices": {"1,5;2,25"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testPriceObj{Price: 3.14, Prices: []float32{1.5, 2.25}}, obj)
+
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, Load(map[string][]string{"price": {"3,14"}}, &obj), &typeErr)
+}
+
+type testBigObj struct {
+	Amount  big.Int    `request:"amount"`
+	Balance *big.Int   `request:"balance"`
+	Rate    big.Float  `request:"rate"`
+	Total   *big.Float `request:"total"`
+}
+
+func TestLoad_BigNumbers(t *testing.T) {
+	var obj testBigObj
+	err := Load(map[string][]string{
+		"amount":  {"123456789012345678901234567890"},
+		"balance": {"-42"},
+		"rate":    {"0.1"},
+		"total":   {"12345678901234567890.123456789"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "123456789012345678901234567890", obj.Amount.String())
+	if assert.NotNil(t, obj.Balance) {
+		assert.Equal(t, int64(-42), obj.Balance.Int64())
+	}
+	assert.Equal(t, "0.1", obj.Rate.Text('f', 1))
+	if assert.NotNil(t, obj.Total) {
+		assert.Equal(t, "12345678901234567890.123456789", obj.Total.Text('f', 9))
+	}
+
+	for _, key := range []string{"amount", "rate"} {
+		err = Load(map[string][]string{key: {"0x1f"}}, &obj)
+		var typeErr *LoadTypeError
+		if assert.ErrorAs(t, err, &typeErr) {
+			assert.Equal(t, "number 0x1f", typeErr.Value)
+			assert.Equal(t, key, typeErr.Field)
+		}
+	}
+}
+
+type testScannerObj struct {
+	Name   sql.NullString `request:"name"`
+	Age    sql.NullInt64  `request:"age"`
+	Active sql.NullBool   `request:"active"`
+	Score  *sql.NullInt32 `request:"score"`
+}
+
+func TestLoad_ScannerFields(t *testing.T) {
+	var obj testScannerObj
+	err := Load(map[string][]string{
+		"name":   {"bob"},
+		"age":    {"42"},
+		"active": {"true"},
+		"score":  {"7"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql.NullString{String: "bob", Valid: true}, obj.Name)
+	assert.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, obj.Age)
+	assert.Equal(t, sql.NullBool{Bool: true, Valid: true}, obj.Active)
+	if assert.NotNil(t, obj.Score) {
+		assert.Equal(t, sql.NullInt32{Int32: 7, Valid: true}, *obj.Score)
+	}
+
+	obj = testScannerObj{}
+	err = Load(map[string][]string{"age": {"old"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "age", typeErr.Field)
+	}
+	assert.False(t, obj.Age.Valid)
+}
+
+type testMappedObj struct {
+	UserID     int
+	HTTPServer string
+	PageSize2  int
+	Note       string `request:"comment"`
+}
+
+func TestDecoder_SetNameMapper(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetNameMapper(SnakeCase)
+
+	var obj testMappedObj
+	err := dec.Decode(map[string][]string{
+		"user_id":     {"7"},
+		"http_server": {"web"},
+		"page_size2":  {"20"},
+		"comment":     {"hi"},
+		"note":        {"ignored"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testMappedObj{UserID: 7, HTTPServer: "web", PageSize2: 20, Note: "hi"}, obj)
+
+	obj = testMappedObj{}
+	assert.NoError(t, Load(map[string][]string{"UserID": {"7"}, "user_id": {"8"}}, &obj))
+	assert.Equal(t, 7, obj.UserID)
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"UserID":     "user_id",
+		"ID":         "id",
+		"HTTPServer": "http_server",
+		"Name":       "name",
+		"PageSize2":  "page_size2",
+		"Top10Items": "top10_items",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, SnakeCase(in), in)
+	}
+}
+
+type testSlicePointerObj struct {
+	IDs  *[]int    `request:"ids"`
+	Tags *[]string `request:"tags"`
+}
+
+func TestLoad_SlicePointerFields(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetSliceDelimiter(",")
+
+	var obj testSlicePointerObj
+	err := dec.Decode(map[string][]string{"ids": {"1,2"}, "tags": {""}}, &obj)
+	assert.NoError(t, err)
+	if assert.NotNil(t, obj.IDs) {
+		assert.Equal(t, []int{1, 2}, *obj.IDs)
+	}
+	if assert.NotNil(t, obj.Tags) {
+		assert.Equal(t, []string{}, *obj.Tags)
+	}
+
+	obj = testSlicePointerObj{}
+	assert.NoError(t, dec.Decode(map[string][]string{"ids": {"null"}}, &obj))
+	assert.Nil(t, obj.IDs)
+	assert.Nil(t, obj.Tags)
+
+	err = dec.Decode(map[string][]string{"ids": {"1,x"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "ids[1]", typeErr.Field)
+	}
+}
+
+func TestLoad_InvalidTarget(t *testing.T) {
+	var n int
+	err := Load(map[string][]string{"n": {"1"}}, &n)
+
+	var targetErr *InvalidTargetError
+	if assert.ErrorAs(t, err, &targetErr) {
+		assert.Equal(t, reflect.TypeOf(&n), targetErr.Type)
+	}
+	assert.EqualError(t, err, "form: target must point to a struct, got *int")
+}
+
+func TestDecoder_WithPrefix(t *testing.T) {
+	data := map[string][]string{
+		"billing_city":  {"NYC"},
+		"billing_zip":   {"10001"},
+		"shipping_city": {"LA"},
+		"name":          {"bob"},
+	}
+
+	dec := NewDecoder()
+	dec.SetStrict(true)
+
+	var billing, shipping testAddress
+	assert.NoError(t, dec.WithPrefix("billing_").Decode(data, &billing))
+	assert.NoError(t, dec.WithPrefix("shipping_").Decode(data, &shipping))
+	assert.Equal(t, testAddress{City: "NYC", Zip: 10001}, billing)
+	assert.Equal(t, testAddress{City: "LA"}, shipping)
+
+	var unknownErr *UnknownFieldError
+	assert.ErrorAs(t, dec.Decode(data, &billing), &unknownErr)
+}
+
+func TestDecoder_Clone(t *testing.T) {
+	base := NewDecoder()
+	base.RegisterConverter(reflect.TypeOf(""), func(value string) (reflect.Value, error) {
+		return reflect.ValueOf(strings.ToUpper(value)), nil
+	})
+
+	strict := base.Clone()
+	strict.SetStrict(true)
+	strict.RegisterConverter(reflect.TypeOf(0), func(value string) (reflect.Value, error) {
+		return reflect.ValueOf(len(value)), nil
+	})
+
+	data := map[string][]string{"city": {"nyc"}, "zip": {"abc"}, "name": {"bob"}}
+
+	var addr testAddress
+	var unknownErr *UnknownFieldError
+	assert.ErrorAs(t, strict.Decode(data, &addr), &unknownErr)
+	assert.Equal(t, testAddress{City: "NYC", Zip: 3}, addr)
+
+	addr = testAddress{}
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, base.Decode(data, &addr), &typeErr)
+	assert.Equal(t, testAddress{City: "NYC"}, addr)
+}
+
+func TestLoadN(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string][]string
+		want int
+	}{
+		{name: "empty", data: map[string][]string{}, want: 0},
+		{name: "repeated values", data: map[string][]string{"tags": {"a", "b"}, "ids": {"1"}}, want: 2},
+		{name: "null and unknown", data: map[string][]string{"tags": {"null"}, "other": {"1"}}, want: 0},
+		{name: "invalid", data: map[string][]string{"ids": {"x"}, "tags": {"a"}}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testSliceObj
+			n, _ := LoadN(tt.data, &obj)
+			assert.Equal(t, tt.want, n)
+		})
+	}
+
+	var obj testDefaultObj
+	n, err := LoadN(map[string][]string{"page": {"2"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var nested testNestedObj
+	n, err = LoadN(map[string][]string{"name": {"bob"}, "address.city": {"NYC"}, "address.zip": {"1"}}, &nested)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+type testCharObj struct {
+	Sep   rune `request:"sep" as:"char"`
+	Quote byte `request:"quote" as:"char"`
+	Code  rune `request:"code"`
+}
+
+func TestLoad_CharFields(t *testing.T) {
+	var obj testCharObj
+	err := Load(map[string][]string{"sep": {"→"}, "quote": {"'"}, "code": {"59"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testCharObj{Sep: '→', Quote: '\'', Code: 59}, obj)
+
+	for _, data := range []map[string][]string{
+		{"sep": {""}},
+		{"sep": {";;"}},
+		{"quote": {"→"}},
+		{"code": {";"}},
+	} {
+		var typeErr *LoadTypeError
+		assert.ErrorAs(t, Load(data, &obj), &typeErr)
+	}
+}
+
+type testSquashObj struct {
+	Page    testPagination `request:",squash"`
+	Address *testAddress   `request:",squash"`
+	Limit   int            `request:"limit"`
+}
+
+func TestLoad_SquashField(t *testing.T) {
+	var obj testSquashObj
+	err := Load(map[string][]string{
+		"page":  {"2"},
+		"limit": {"50"},
+		"city":  {"NYC"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, obj.Page.Page)
+	assert.Equal(t, 0, obj.Page.Limit)
+	assert.Equal(t, 50, obj.Limit)
+	if assert.NotNil(t, obj.Address) {
+		assert.Equal(t, "NYC", obj.Address.City)
+	}
+}
+
+func TestDecoder_SetArrayBrackets(t *testing.T) {
+	data := map[string][]string{
+		"tags[]": {"a", "b"},
+		"ids":    {"1"},
+		"ids[]":  {"2", "3"},
+	}
+
+	var obj testSliceObj
+	assert.NoError(t, Load(data, &obj))
+	assert.Nil(t, obj.Tags)
+	assert.Equal(t, []int{1}, obj.IDs)
+
+	dec := NewDecoder()
+	dec.SetArrayBrackets(true)
+	dec.SetStrict(true)
+	obj = testSliceObj{}
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, []string{"a", "b"}, obj.Tags)
+	assert.Equal(t, []int{1, 2, 3}, obj.IDs)
+	assert.Equal(t, []string{"1"}, data["ids"])
+
+	var ptrs testSlicePointerObj
+	assert.NoError(t, dec.Decode(map[string][]string{"ids[]": {"4"}}, &ptrs))
+	if assert.NotNil(t, ptrs.IDs) {
+		assert.Equal(t, []int{4}, *ptrs.IDs)
+	}
+}
+
+func TestDecoder_SetRejectDuplicates(t *testing.T) {
+	data := map[string][]string{"age": {"1", "2"}, "small": {"3"}}
+
+	var obj testIntObj
+	assert.NoError(t, Load(data, &obj))
+	assert.Equal(t, testIntObj{Age: 1, Small: 3}, obj)
+
+	dec := NewDecoder()
+	dec.SetRejectDuplicates(true)
+	obj = testIntObj{}
+	err := dec.Decode(data, &obj)
+	var dupErr *DuplicateValueError
+	if assert.ErrorAs(t, err, &dupErr) {
+		assert.Equal(t, "age", dupErr.Field)
+	}
+	assert.EqualError(t, err, "form: duplicate values for field age")
+	assert.Equal(t, testIntObj{Small: 3}, obj)
+
+	var slices testSliceObj
+	assert.NoError(t, dec.Decode(map[string][]string{"ids": {"1", "2"}}, &slices))
+	assert.Equal(t, []int{1, 2}, slices.IDs)
+}
+
+type testSection struct {
+	Title   string      `request:"title"`
+	Order   int         `request:"order"`
+	Address testAddress `request:"address"`
+	Items   []testItem  `request:"items"`
+}
+
+type testSectionsObj struct {
+	Sections map[string]testSection  `request:"sections"`
+	Extras   map[string]*testSection `request:"extras"`
+}
+
+func TestLoad_StructMapFields(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetStrict(true)
+
+	var obj testSectionsObj
+	err := dec.Decode(map[string][]string{
+		"sections[general][title]":          {"General"},
+		"sections[general][order]":          {"1"},
+		"sections[general][address][city]":  {"NYC"},
+		"sections[general][items][1][name]": {"b"},
+		"sections[ads].title":               {"Ads"},
+		"extras[x][order]":                  {"3"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]testSection{
+		"general": {
+			Title:   "General",
+			Order:   1,
+			Address: testAddress{City: "NYC"},
+			Items:   []testItem{{}, {Name: "b"}},
+		},
+		"ads": {Title: "Ads"},
+	}, obj.Sections)
+	if assert.Contains(t, obj.Extras, "x") {
+		assert.Equal(t, &testSection{Order: 3}, obj.Extras["x"])
+	}
+
+	var unknownErr *UnknownFieldError
+	if assert.ErrorAs(t, dec.Decode(map[string][]string{"sections[general][bogus]": {"1"}}, &obj), &unknownErr) {
+		assert.Equal(t, "sections[general][bogus]", unknownErr.Key)
+	}
+
+	obj = testSectionsObj{}
+	assert.NoError(t, Load(map[string][]string{"title": {"x"}}, &obj))
+	assert.Nil(t, obj.Sections)
+
+	err = Load(map[string][]string{"sections[general][order]": {"first"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "sections[general].order", typeErr.Field)
+	}
+}
+
+type testRequiredSection struct {
+	Title string `request:"title,required"`
+	Order int    `request:"order"`
+}
+
+func TestLoad_StructMapRequiredField(t *testing.T) {
+	var obj struct {
+		Sections map[string]testRequiredSection `request:"sections"`
+	}
+	err := Load(map[string][]string{"sections[general][order]": {"1"}}, &obj)
+	var missingErr *MissingFieldError
+	if assert.ErrorAs(t, err, &missingErr) {
+		assert.Equal(t, "sections[general].title", missingErr.Field)
+	}
+	assert.Equal(t, 1, obj.Sections["general"].Order)
+}
+
+func TestDecoder_SetSliceAppend(t *testing.T) {
+	data := map[string][]string{"ids": {"3", "4"}, "tags": {"c"}}
+
+	ids := []int{1, 2, 9}
+	obj := testSliceObj{IDs: ids, Tags: []string{"a", "b"}}
+	assert.NoError(t, Load(data, &obj))
+	assert.Equal(t, []int{3, 4}, obj.IDs)
+	assert.Equal(t, []string{"c"}, obj.Tags)
+	assert.Equal(t, []int{1, 2, 9}, ids)
+
+	dec := NewDecoder()
+	dec.SetSliceAppend(true)
+	obj = testSliceObj{IDs: ids}
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, []int{1, 2, 9, 3, 4}, obj.IDs)
+	assert.Equal(t, []string{"c"}, obj.Tags)
+
+	err := dec.Decode(map[string][]string{"ids": {"x"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "ids[5]", typeErr.Field)
+	}
+}
+
+type testBoolSetObj struct {
+	Active  bool `request:"active,truthy=Y,falsy=N"`
+	Visible bool `request:"visible,truthy=shown|public"`
+	Legacy  bool `request:"legacy,truthy=-1,falsy=0"`
+}
+
+func TestLoad_BoolValueSets(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string][]string
+		want testBoolSetObj
+	}{
+		{name: "custom true", data: map[string][]string{"active": {"Y"}}, want: testBoolSetObj{Active: true}},
+		{name: "custom false", data: map[string][]string{"active": {"n"}}, want: testBoolSetObj{}},
+		{name: "alternative", data: map[string][]string{"visible": {"public"}}, want: testBoolSetObj{Visible: true}},
+		{name: "global false", data: map[string][]string{"visible": {"false"}}, want: testBoolSetObj{}},
+		{name: "negative true", data: map[string][]string{"legacy": {"-1"}}, want: testBoolSetObj{Legacy: true}},
+		{name: "zero false", data: map[string][]string{"legacy": {"0"}}, want: testBoolSetObj{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testBoolSetObj
+			assert.NoError(t, Load(tt.data, &obj))
+			assert.Equal(t, tt.want, obj)
+		})
+	}
+
+	for _, data := range []map[string][]string{
+		{"active": {"true"}},
+		{"active": {"0"}},
+		{"visible": {"yes"}},
+		{"legacy": {"1"}},
+		{"legacy": {"-"}},
+	} {
+		var obj testBoolSetObj
+		var typeErr *LoadTypeError
+		assert.ErrorAs(t, Load(data, &obj), &typeErr)
+	}
+}
+
+type testPanicText struct{ p *int }
+
+func (v *testPanicText) UnmarshalText([]byte) error {
+	*v.p = 1
+	return nil
+}
+
+type testPanicObj struct {
+	Broken testPanicText `request:"broken"`
+	Name   string        `request:"name"`
+}
+
+func TestLoad_PanicInField(t *testing.T) {
+	var obj testPanicObj
+	var err error
+	assert.NotPanics(t, func() {
+		err = Load(map[string][]string{"broken": {"x"}, "name": {"bob"}}, &obj)
+	})
+
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "broken", typeErr.Field)
+		assert.Equal(t, "testPanicObj", typeErr.Struct)
+		assert.Contains(t, typeErr.Value, "panic")
+	}
+	assert.Equal(t, "bob", obj.Name)
+}
+
+type testFloatRangeObj struct {
+	Rate  float64   `request:"rate"`
+	Small float32   `request:"small"`
+	Rates []float64 `request:"rates"`
+}
+
+func TestLoad_NumberOverflowValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  map[string][]string
+		value string
+	}{
+		{name: "scientific", data: map[string][]string{"rate": {"1e400"}}, value: "number overflow 1e400"},
+		{name: "float32", data: map[string][]string{"small": {"1e39"}}, value: "number overflow 1e39"},
+		{name: "slice element", data: map[string][]string{"rates": {"1", "-1e400"}}, value: "array overflow -1e400"},
+		{name: "syntax", data: map[string][]string{"rate": {"abc"}}, value: "number abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj testFloatRangeObj
+			var typeErr *LoadTypeError
+			if assert.ErrorAs(t, Load(tt.data, &obj), &typeErr) {
+				assert.Equal(t, tt.value, typeErr.Value)
+			}
+		})
+	}
+
+	var obj testFloatRangeObj
+	assert.NoError(t, Load(map[string][]string{"rate": {"1.5e-3"}}, &obj))
+	assert.Equal(t, 1.5e-3, obj.Rate)
+
+	var width testWidthObj
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, Load(map[string][]string{"small": {"300"}}, &width), &typeErr) {
+		assert.Equal(t, "number overflow 300", typeErr.Value)
+	}
+}
+
+func TestDecoder_SetKeyNormalizer(t *testing.T) {
+	calls := 0
+	dec := NewDecoder()
+	dec.SetKeyNormalizer(func(key string) string {
+		calls++
+		key = strings.TrimPrefix(key, "form_")
+		return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	})
+
+	var obj testDefaultObj
+	err := dec.Decode(map[string][]string{
+		"form_Page": {"3"},
+		"SORT":      {"name"},
+		"limit":     {"10"},
+		"time-out":  {"1s"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testDefaultObj{Page: 3, Sort: "name", Timeout: 5 * time.Second, Limit: 10}, obj)
+	assert.Equal(t, 4, calls)
+}
+
+type testRangeObj struct {
+	Start int `request:"start"`
+	End   int `request:"end"`
+}
+
+var errInvalidRange = errors.New("start must be before end")
+
+func (r testRangeObj) Validate() error {
+	if r.Start >= r.End {
+		return errInvalidRange
+	}
+	return nil
+}
+
+func TestLoad_Validator(t *testing.T) {
+	var obj testRangeObj
+	assert.NoError(t, Load(map[string][]string{"start": {"1"}, "end": {"2"}}, &obj))
+
+	err := Load(map[string][]string{"start": {"3"}, "end": {"2"}}, &obj)
+	assert.ErrorIs(t, err, errInvalidRange)
+
+	err = Load(map[string][]string{"start": {"x"}}, &obj)
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, err, &typeErr)
+	assert.False(t, errors.Is(err, errInvalidRange))
+}
+
+func TestLoad_HexFloats(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{value: "0x1.5p3", want: 10.5},
+		{value: "0x1p1023", want: math.Ldexp(1, 1023)},
+		{value: "0x1.fffffffffffffp1023", want: math.MaxFloat64},
+		{value: "-0x1p-1074", want: -math.SmallestNonzeroFloat64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var obj testFloatRangeObj
+			assert.NoError(t, Load(map[string][]string{"rate": {tt.value}, "rates": {tt.value}}, &obj))
+			assert.Equal(t, tt.want, obj.Rate)
+			assert.Equal(t, []float64{tt.want}, obj.Rates)
+		})
+	}
+
+	var obj testFloatRangeObj
+	assert.NoError(t, Load(map[string][]string{"small": {"0x1.fffffep127"}}, &obj))
+	assert.Equal(t, float32(math.MaxFloat32), obj.Small)
+
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, Load(map[string][]string{"rate": {"0x1p1024"}}, &obj), &typeErr) {
+		assert.Equal(t, "number overflow 0x1p1024", typeErr.Value)
+	}
+	if assert.ErrorAs(t, Load(map[string][]string{"small": {"0x1p128"}}, &obj), &typeErr) {
+		assert.Equal(t, "number overflow 0x1p128", typeErr.Value)
+	}
+}
+
+type testCoerceObj struct {
+	Enabled int   `request:"enabled" coerce:"bool"`
+	Active  uint8 `request:"active" coerce:"bool"`
+	Count   int   `request:"count"`
+}
+
+func TestLoad_CoerceBool(t *testing.T) {
+	var obj testCoerceObj
+	err := Load(map[string][]string{"enabled": {"true"}, "active": {"on"}, "count": {"3"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testCoerceObj{Enabled: 1, Active: 1, Count: 3}, obj)
+
+	err = Load(map[string][]string{"enabled": {"off"}, "active": {"false"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, obj.Enabled)
+	assert.Equal(t, uint8(0), obj.Active)
+
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, Load(map[string][]string{"enabled": {"maybe"}}, &obj), &typeErr)
+	assert.ErrorAs(t, Load(map[string][]string{"count": {"true"}}, &obj), &typeErr)
+}
+
+func TestDecoder_SetMaxSliceLen(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetMaxSliceLen(2)
+
+	var obj testSliceObj
+	assert.NoError(t, dec.Decode(map[string][]string{"ids": {"1", "2"}}, &obj))
+	assert.Equal(t, []int{1, 2}, obj.IDs)
+
+	err := dec.Decode(map[string][]string{"ids": {"3", "4", "5"}, "tags": {"a"}}, &obj)
+	var lenErr *SliceLenError
+	if assert.ErrorAs(t, err, &lenErr) {
+		assert.Equal(t, "ids", lenErr.Field)
+		assert.Equal(t, 2, lenErr.Max)
+	}
+	assert.Equal(t, []int{1, 2}, obj.IDs)
+
+	var order testOrderObj
+	err = dec.Decode(map[string][]string{"items[1000000].name": {"x"}}, &order)
+	assert.ErrorAs(t, err, &lenErr)
+	assert.Nil(t, order.Items)
+
+	dec.SetSliceAppend(true)
+	err = dec.Decode(map[string][]string{"ids": {"3"}}, &obj)
+	assert.ErrorAs(t, err, &lenErr)
+
+	ids := make([]string, 1001)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	obj = testSliceObj{}
+	assert.ErrorAs(t, Load(map[string][]string{"ids": ids}, &obj), &lenErr)
+	assert.Nil(t, obj.IDs)
+
+	dec = NewDecoder()
+	dec.SetMaxSliceLen(0)
+	assert.NoError(t, dec.Decode(map[string][]string{"ids": ids}, &obj))
+	assert.Len(t, obj.IDs, 1001)
+	assert.ErrorAs(t, dec.Decode(map[string][]string{"items[1000].qty": {"1"}}, &order), &lenErr)
+}
+
+type testAccountStatus string
+
+type testEnumObj struct {
+	Status testAccountStatus `request:"status" enum:"active,inactive,banned"`
+	Roles  []string          `request:"roles" enum:"admin,user"`
+	Plan   string            `request:"plan,required" enum:"free,pro"`
+}
+
+func TestLoad_EnumFields(t *testing.T) {
+	var obj testEnumObj
+	err := Load(map[string][]string{"status": {"banned"}, "roles": {"admin", "user"}, "plan": {"pro"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testEnumObj{Status: "banned", Roles: []string{"admin", "user"}, Plan: "pro"}, obj)
+
+	var typeErr *LoadTypeError
+	err = Load(map[string][]string{"status": {"deleted"}, "plan": {"pro"}}, &obj)
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "status", typeErr.Field)
+		assert.Equal(t, "string deleted", typeErr.Value)
+	}
+	err = Load(map[string][]string{"roles": {"admin", "root"}, "plan": {"pro"}}, &obj)
+	assert.ErrorAs(t, err, &typeErr)
+
+	obj = testEnumObj{}
+	assert.NoError(t, Load(map[string][]string{"status": {""}, "plan": {""}}, &obj))
+	assert.Equal(t, testEnumObj{}, obj)
+
+	dec := NewDecoder()
+	dec.SetSkipEmpty(true)
+	var missingErr *MissingFieldError
+	assert.ErrorAs(t, dec.Decode(map[string][]string{"plan": {""}}, &obj), &missingErr)
+}
+
+func TestDecoder_SetSuggestUnknown(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetSuggestUnknown(true)
+
+	var obj testStatusObj
+	var errs []string
+	dec.SetCollectErrors(true)
+	err := dec.Decode(map[string][]string{"Statu": {"ok"}, "typ": {"1"}, "utm_source": {"ad"}}, &obj)
+	var loadErrs LoadErrors
+	if assert.ErrorAs(t, err, &loadErrs) {
+		for _, e := range loadErrs {
+			errs = append(errs, e.Error())
+		}
+	}
+	assert.Equal(t, []string{
+		"form: unknown field Statu (did you mean Status?)",
+		"form: unknown field typ (did you mean type?)",
+	}, errs)
+
+	dec.SetStrict(true)
+	dec.SetCollectErrors(false)
+	err = dec.Decode(map[string][]string{"utm_source": {"ad"}}, &obj)
+	var unknownErr *UnknownFieldError
+	if assert.ErrorAs(t, err, &unknownErr) {
+		assert.Equal(t, "utm_source", unknownErr.Key)
+		assert.Empty(t, unknownErr.Suggestion)
+	}
+
+	assert.NoError(t, Load(map[string][]string{"typ": {"1"}}, &obj))
+}
+
+type testEnvDefaultObj struct {
+	PageSize int    `request:"page_size" default:"$ENV:FORM_TEST_PAGE_SIZE|20"`
+	Region   string `request:"region" default:"$ENV:FORM_TEST_REGION"`
+}
+
+func TestLoad_EnvDefaults(t *testing.T) {
+	t.Setenv("FORM_TEST_PAGE_SIZE", "50")
+	t.Setenv("FORM_TEST_REGION", "eu")
+
+	var obj testEnvDefaultObj
+	assert.NoError(t, Load(map[string][]string{}, &obj))
+	assert.Equal(t, testEnvDefaultObj{PageSize: 50, Region: "eu"}, obj)
+
+	obj = testEnvDefaultObj{}
+	assert.NoError(t, Load(map[string][]string{"page_size": {"10"}}, &obj))
+	assert.Equal(t, 10, obj.PageSize)
+
+	t.Setenv("FORM_TEST_PAGE_SIZE", "many")
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, Load(map[string][]string{}, &obj), &typeErr)
+}
+
+func TestLoad_EnvDefaultsUnset(t *testing.T) {
+	var obj testEnvDefaultObj
+	assert.NoError(t, Load(map[string][]string{}, &obj))
+	assert.Equal(t, testEnvDefaultObj{PageSize: 20}, obj)
+}
+
+type testInlineObj struct {
+	Page   int `request:"page"`
+	Filter struct {
+		Status string `request:"status"`
+		Range  struct {
+			From int `request:"from"`
+		} `request:"range"`
+	} `request:"filter"`
+	Sorts []struct {
+		Field string `request:"field"`
+	} `request:"sorts"`
+}
+
+func TestLoad_InlineStructs(t *testing.T) {
+	var obj testInlineObj
+	err := Load(map[string][]string{
+		"page":              {"2"},
+		"filter.status":     {"active"},
+		"filter.range.from": {"10"},
+		"sorts[0].field":    {"name"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, obj.Page)
+	assert.Equal(t, "active", obj.Filter.Status)
+	assert.Equal(t, 10, obj.Filter.Range.From)
+	if assert.Len(t, obj.Sorts, 1) {
+		assert.Equal(t, "name", obj.Sorts[0].Field)
+	}
+
+	err = Load(map[string][]string{"filter.range.from": {"x"}}, &obj)
+	assert.EqualError(t, err, "form: cannot load number x into Go struct field testInlineObj.filter.range.from of type int")
+
+	var req struct {
+		Filter struct {
+			Status string `request:"status"`
+		} `request:"filter"`
+	}
+	assert.NoError(t, Load(map[string][]string{"filter.status": {"banned"}}, &req))
+	assert.Equal(t, "banned", req.Filter.Status)
+}
+
+type testPartialObj struct {
+	Name   string   `request:"name"`
+	Age    int      `request:"age"`
+	Score  float64  `request:"score"`
+	Tags   []int    `request:"tags"`
+	Limits *[]int   `request:"limits"`
+	Codes  [2]uint8 `request:"codes"`
+}
+
+func TestLoad_PartialResults(t *testing.T) {
+	obj := testPartialObj{Age: 30}
+	err := Load(map[string][]string{"name": {"bob"}, "age": {"old"}, "score": {"9.5"}}, &obj)
+
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "age", typeErr.Field)
+	}
+	assert.Equal(t, testPartialObj{Name: "bob", Age: 30, Score: 9.5}, obj)
+
+	obj = testPartialObj{Tags: []int{9, 9}, Codes: [2]uint8{7, 7}}
+	err = Load(map[string][]string{
+		"tags":   {"1", "x"},
+		"limits": {"2", "y"},
+		"codes":  {"1", "300"},
+		"name":   {"ann"},
+	}, &obj)
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "tags[1]", typeErr.Field)
+	}
+	assert.Equal(t, testPartialObj{Name: "ann", Tags: []int{9, 9}, Codes: [2]uint8{7, 7}}, obj)
+}
+
+type testKeyValueObj struct {
+	Params map[string]string `request:"params,kv"`
+	Limits map[string]int    `request:"limits,kv"`
+}
+
+func TestLoad_KeyValueMap(t *testing.T) {
+	var obj testKeyValueObj
+	err := Load(map[string][]string{"params": {"a:1,b:2:3"}, "limits": {"x:1", "y:2"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "2:3"}, obj.Params)
+	assert.Equal(t, map[string]int{"x": 1, "y": 2}, obj.Limits)
+
+	obj = testKeyValueObj{}
+	err = Load(map[string][]string{"params": {"a:1,b"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "params", typeErr.Field)
+		assert.Equal(t, "map b", typeErr.Value)
+	}
+	assert.Equal(t, map[string]string{"a": "1"}, obj.Params)
+
+	err = Load(map[string][]string{"limits": {"x:many"}}, &obj)
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "limits[x]", typeErr.Field)
+	}
+
+	dec := NewDecoder()
+	dec.SetSliceDelimiter(";")
+	dec.SetKeyValueSeparator("=")
+	obj = testKeyValueObj{}
+	assert.NoError(t, dec.Decode(map[string][]string{"params": {"a=1;b=2"}}, &obj))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, obj.Params)
+}
+
+type testPayment interface {
+	Method() string
+}
+
+type testCardPayment struct {
+	Number string `request:"number"`
+	CVV    int    `request:"cvv"`
+}
+
+func (*testCardPayment) Method() string { return "card" }
+
+type testIBAN string
+
+func (testIBAN) Method() string { return "iban" }
+
+type testCheckoutObj struct {
+	Kind    string      `request:"type"`
+	Payment testPayment `request:"payment"`
+}
+
+func TestDecoder_RegisterInterfaceImpl(t *testing.T) {
+	dec := NewDecoder()
+	dec.RegisterInterfaceImpl(reflect.TypeOf((*testPayment)(nil)).Elem(), "type", map[string]reflect.Type{
+		"card": reflect.TypeOf(&testCardPayment{}),
+		"iban": reflect.TypeOf(testIBAN("")),
+	})
+
+	var obj testCheckoutObj
+	err := dec.Decode(map[string][]string{
+		"type":           {"card"},
+		"payment.number": {"4242"},
+		"payment.cvv":    {"123"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testCheckoutObj{Kind: "card", Payment: &testCardPayment{Number: "4242", CVV: 123}}, obj)
+
+	obj = testCheckoutObj{}
+	assert.NoError(t, dec.Decode(map[string][]string{"type": {"iban"}, "payment": {"DE89"}}, &obj))
+	assert.Equal(t, testIBAN("DE89"), obj.Payment)
+
+	obj = testCheckoutObj{}
+	err = dec.Decode(map[string][]string{"type": {"crypto"}, "payment": {"x"}}, &obj)
+	assert.EqualError(t, err, "form: cannot load discriminator crypto into Go struct field testCheckoutObj.payment of type form.testPayment")
+	assert.Nil(t, obj.Payment)
+
+	assert.NoError(t, dec.Decode(map[string][]string{"payment.number": {"4242"}}, &obj))
+	assert.Nil(t, obj.Payment)
+
+	prior := &testCardPayment{Number: "1111"}
+	obj = testCheckoutObj{Payment: prior}
+	err = dec.Decode(map[string][]string{"type": {"card"}, "payment.number": {"4242"}, "payment.cvv": {"abc"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "payment.cvv", typeErr.Field)
+	}
+	assert.True(t, obj.Payment == prior)
+	assert.Equal(t, "1111", prior.Number)
+}
+
+type testMessageObj struct {
+	Email string `request:"email,required,msg=Please, provide an email"`
+	Age   int    `request:"age,msg=Age must be a number"`
+	Zip   int    `request:"zip"`
+}
+
+func TestLoad_FieldMessages(t *testing.T) {
+	dec := NewDecoder()
+	dec.SetCollectErrors(true)
+
+	var obj testMessageObj
+	err := dec.Decode(map[string][]string{"age": {"old"}, "zip": {"x"}}, &obj)
+
+	var errs LoadErrors
+	if assert.ErrorAs(t, err, &errs) {
+		assert.Equal(t, map[string]string{
+			"email": "Please, provide an email",
+			"age":   "Age must be a number",
+			"zip":   "invalid number x",
+		}, errs.ToMap())
+	}
+
+	var missingErr *MissingFieldError
+	if assert.ErrorAs(t, err, &missingErr) {
+		assert.EqualError(t, missingErr, "Please, provide an email")
+	}
+	assert.Equal(t, []string{"email", "age", "zip"}, FieldKeys(&obj))
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, Load(map[string][]string{"email": {"a@b.c"}, "age": {"old"}}, &obj), &typeErr) {
+		assert.Equal(t, "Age must be a number", typeErr.Msg)
+		assert.Equal(t, "number old", typeErr.Value)
+	}
+}
+
+type testUnixObj struct {
+	CreatedAt int64 `request:"created_at" as:"unix"`
+	UpdatedAt int64 `request:"updated_at" as:"unixmilli"`
+	Day       int64 `request:"day" as:"unix" layout:"2006-01-02"`
+}
+
+func TestLoad_UnixFields(t *testing.T) {
+	var obj testUnixObj
+	err := Load(map[string][]string{
+		"created_at": {"2023-01-02T15:04:05Z"},
+		"updated_at": {"2023-01-02T15:04:05.123Z"},
+		"day":        {"2023-01-02"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testUnixObj{CreatedAt: 1672671845, UpdatedAt: 1672671845123, Day: 1672617600}, obj)
+
+	assert.NoError(t, Load(map[string][]string{"created_at": {"1700000000"}, "updated_at": {"-1"}}, &obj))
+	assert.Equal(t, int64(1700000000), obj.CreatedAt)
+	assert.Equal(t, int64(-1), obj.UpdatedAt)
+
+	var typeErr *LoadTypeError
+	assert.ErrorAs(t, Load(map[string][]string{"created_at": {"yesterday"}}, &obj), &typeErr)
+}
+
+type testKeysNode struct {
+	Name     string         `request:"name"`
+	Children []testKeysNode `request:"children"`
+}
+
+type testKeysObj struct {
+	testPagination
+	Query   string `request:"q,query,search"`
+	Skipped string `request:"-"`
+	secret  string
+	Address testAddress       `request:"address"`
+	Items   []testItem        `request:"items"`
+	Attrs   map[string]string `request:"attrs"`
+	Params  map[string]string `request:"params,kv"`
+	Raw     map[string]string `request:"_raw"`
+	Tree    testKeysNode      `request:"tree"`
+	Created time.Time         `request:"created"`
+}
+
+func TestFieldKeys(t *testing.T) {
+	assert.Equal(t, []string{
+		"page", "limit",
+		"q", "query", "search",
+		"address.city", "address.zip",
+		"items[n].name", "items[n].qty",
+		"attrs[key]",
+		"params",
+		"tree.name",
+		"created",
+	}, FieldKeys(&testKeysObj{}))
+
+	dec := NewDecoder()
+	dec.SetNameMapper(SnakeCase)
+	assert.Equal(t, []string{"billing_status", "billing_type"}, dec.WithPrefix("billing_").FieldKeys(testStatusObj{}))
+
+	assert.Nil(t, FieldKeys(42))
+	assert.Nil(t, FieldKeys(nil))
+}
+
+type testPresenceObj struct {
+	Verbose bool  `request:"verbose,presence"`
+	Debug   *bool `request:"debug,presence"`
+	Quiet   bool  `request:"quiet"`
+}
+
+func TestLoad_PresenceFlags(t *testing.T) {
+	var obj testPresenceObj
+	data, err := url.ParseQuery("verbose&debug=&quiet")
+	assert.NoError(t, err)
+	assert.NoError(t, Load(data, &obj))
+	assert.True(t, obj.Verbose)
+	if assert.NotNil(t, obj.Debug) {
+		assert.True(t, *obj.Debug)
+	}
+	assert.False(t, obj.Quiet)
+
+	obj = testPresenceObj{}
+	assert.NoError(t, Load(map[string][]string{"verbose": {"false"}}, &obj))
+	assert.False(t, obj.Verbose)
+	assert.Nil(t, obj.Debug)
+
+	dec := NewDecoder()
+	dec.SetSkipEmpty(true)
+	assert.NoError(t, dec.Decode(map[string][]string{"verbose": {""}}, &obj))
+	assert.True(t, obj.Verbose)
+}
+
+type testPointerItemsObj struct {
+	Items []*testItem `request:"items"`
+	Names []*string   `request:"names"`
+}
+
+func TestLoad_PointerSliceElements(t *testing.T) {
+	var obj testPointerItemsObj
+	err := Load(map[string][]string{
+		"items[0].name": {"a"},
+		"items[2].name": {"c"},
+		"items[2].qty":  {"3"},
+		"names":         {"x", "null"},
+	}, &obj)
+	assert.NoError(t, err)
+
+	if assert.Len(t, obj.Items, 3) {
+		assert.Equal(t, &testItem{Name: "a"}, obj.Items[0])
+		assert.Nil(t, obj.Items[1])
+		assert.Equal(t, &testItem{Name: "c", Qty: 3}, obj.Items[2])
+	}
+	if assert.Len(t, obj.Names, 2) {
+		assert.Equal(t, "x", *obj.Names[0])
+		assert.Nil(t, obj.Names[1])
+	}
+
+	first := obj.Items[0]
+	err = Load(map[string][]string{"items[0].qty": {"many"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "items[0].qty", typeErr.Field)
+	}
+	assert.True(t, first == obj.Items[0])
+	assert.Equal(t, []string{"items[n].name", "items[n].qty", "names"}, FieldKeys(&obj))
+}
+
+type testURLObj struct {
+	Redirect url.URL  `request:"redirect"`
+	Callback *url.URL `request:"callback"`
+}
+
+func TestLoad_URLFields(t *testing.T) {
+	var obj testURLObj
+	data, err := url.ParseQuery("redirect=https%3A%2F%2Fexample.com%2Fdone%3Fok%3D1&callback=https://api.example.com/cb")
+	assert.NoError(t, err)
+	assert.NoError(t, Load(data, &obj))
+	assert.Equal(t, "https://example.com/done?ok=1", obj.Redirect.String())
+	assert.Equal(t, "example.com", obj.Redirect.Host)
+	if assert.NotNil(t, obj.Callback) {
+		assert.Equal(t, "/cb", obj.Callback.Path)
+	}
+
+	obj = testURLObj{}
+	assert.NoError(t, Load(map[string][]string{"callback": {"null"}}, &obj))
+	assert.Nil(t, obj.Callback)
+
+	err = Load(map[string][]string{"callback": {"http://[::1"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "url http://[::1", typeErr.Value)
+		assert.Equal(t, "callback", typeErr.Field)
+	}
+	assert.Nil(t, obj.Callback)
+}
+
+type testLenientObj struct {
+	Age    int            `request:"age"`
+	Rate   *float64       `request:"rate"`
+	Active bool           `request:"active"`
+	IDs    []int          `request:"ids"`
+	Scores map[string]int `request:"scores"`
+	Name   string         `request:"name,required"`
+}
+
+func TestDecoder_SetLenient(t *testing.T) {
+	data := map[string][]string{
+		"age":        {"old"},
+		"rate":       {"high"},
+		"active":     {"maybe"},
+		"ids":        {"1", "x", "3"},
+		"scores[a]":  {"1"},
+		"scores[b]":  {"many"},
+		"name":       {"bob"},
+		"unexpected": {"1"},
+	}
+
+	dec := NewDecoder()
+	dec.SetLenient(true)
+	obj := testLenientObj{Age: 30}
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.Equal(t, testLenientObj{
+		IDs:    []int{1, 0, 3},
+		Scores: map[string]int{"a": 1},
+		Name:   "bob",
+	}, obj)
+
+	rate := 1.5
+	obj = testLenientObj{Rate: &rate}
+	assert.NoError(t, dec.Decode(data, &obj))
+	assert.True(t, obj.Rate == &rate)
+	assert.Zero(t, rate)
+
+	var missingErr *MissingFieldError
+	assert.ErrorAs(t, dec.Decode(map[string][]string{"age": {"x"}}, &obj), &missingErr)
+
+	dec = NewDecoder()
+	dec.SetCollectErrors(true)
+	var errs LoadErrors
+	if assert.ErrorAs(t, dec.Decode(data, &testLenientObj{}), &errs) {
+		assert.Len(t, errs, 5)
+	}
+}
+
+func parseTestMoneyValue(s string) (testMoney, error) {
+	v, err := parseTestMoney(s)
+	if err != nil {
+		return testMoney{}, err
+	}
+	return v.Interface().(testMoney), nil
+}
+
+func TestRegisterParseFunc(t *testing.T) {
+	dec := NewDecoder()
+	RegisterParseFunc(dec, parseTestMoneyValue)
+	RegisterParseFunc(dec, func(s string) (fmt.Stringer, error) {
+		return time.ParseDuration(s)
+	})
+
+	var obj testMoneyObj
+	err := dec.Decode(map[string][]string{"price": {"12.34"}, "prices": {"0.99"}}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, testMoneyObj{Price: testMoney{Cents: 1234}, Prices: []testMoney{{Cents: 99}}}, obj)
+
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, dec.Decode(map[string][]string{"price": {"free"}}, &obj), &typeErr) {
+		assert.Equal(t, "price", typeErr.Field)
+	}
+
+	var wait struct {
+		Wait fmt.Stringer `request:"wait"`
+	}
+	assert.NoError(t, dec.Decode(map[string][]string{"wait": {"1m"}}, &wait))
+	assert.Equal(t, time.Minute, wait.Wait)
+}
+
+type testLinkedObj struct {
+	Name string         `request:"name"`
+	Addr *testAddress   `request:"addr"`
+	Next *testLinkedObj `request:"next"`
+}
+
+func TestLoad_NestedStructPointer(t *testing.T) {
+	var obj testLinkedObj
+	err := Load(map[string][]string{
+		"name":           {"a"},
+		"addr.city":      {"NYC"},
+		"next.name":      {"b"},
+		"next.next.addr": {"ignored"},
+	}, &obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", obj.Name)
+	if assert.NotNil(t, obj.Addr) {
+		assert.Equal(t, testAddress{City: "NYC"}, *obj.Addr)
+	}
+	if assert.NotNil(t, obj.Next) {
+		assert.Equal(t, "b", obj.Next.Name)
+		assert.Nil(t, obj.Next.Addr)
+		assert.Nil(t, obj.Next.Next)
+	}
+
+	obj = testLinkedObj{}
+	assert.NoError(t, Load(map[string][]string{"name": {"a"}, "addr.unknown": {"x"}}, &obj))
+	assert.Nil(t, obj.Addr)
+	assert.Nil(t, obj.Next)
+
+	addr := &testAddress{City: "LA"}
+	obj = testLinkedObj{Addr: addr}
+	err = Load(map[string][]string{"addr.zip": {"x"}}, &obj)
+	var typeErr *LoadTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, "addr.zip", typeErr.Field)
+	}
+	assert.True(t, addr == obj.Addr)
+	assert.Equal(t, []string{"name", "addr.city", "addr.zip"}, FieldKeys(&obj))
+}
+
+// RecursiveNode and the Mutual types are exported because embedded
+// pointers to unexported structs are skipped.
+type RecursiveNode struct {
+	*RecursiveNode
+	X int `request:"x"`
+}
+
+type MutualA struct {
+	*MutualB
+	A int `request:"a"`
+}
+
+type MutualB struct {
+	*MutualA
+	B int `request:"b"`
+}
+
+func TestLoad_RecursiveEmbedding(t *testing.T) {
+	var node RecursiveNode
+	assert.NoError(t, Load(map[string][]string{"x": {"1"}, "RecursiveNode.x": {"2"}}, &node))
+	assert.Equal(t, 1, node.X)
+	if assert.NotNil(t, node.RecursiveNode) {
+		assert.Equal(t, 2, node.RecursiveNode.X)
+	}
+
+	var a MutualA
+	assert.NoError(t, Load(map[string][]string{"a": {"1"}, "b": {"2"}}, &a))
+	assert.Equal(t, 1, a.A)
+	if assert.NotNil(t, a.MutualB) {
+		assert.Equal(t, 2, a.B)
+		assert.Nil(t, a.MutualB.MutualA)
+	}
+	assert.Equal(t, []string{"b", "a"}, FieldKeys(&a))
+}
+
+type testSquashListObj struct {
+	Next  *testSquashListObj `request:",squash"`
+	Value int                `request:"v"`
+}
+
+func TestLoad_RecursiveSquash(t *testing.T) {
+	var obj testSquashListObj
+	assert.NoError(t, Load(map[string][]string{"v": {"1"}, "Next.v": {"2"}}, &obj))
+	assert.Equal(t, 1, obj.Value)
+	if assert.NotNil(t, obj.Next) {
+		assert.Equal(t, 2, obj.Next.Value)
+		assert.Nil(t, obj.Next.Next)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Urvantsev Evgenii. All rights reserved.
+// Use of this source code is governed by a BSD3-style
+// license that can be found in the LICENSE file.
+
+package form
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DecodeRequest parses the form of r and loads it into the struct pointed
+// to by v. Like r.Form, the values of the POST, PATCH or PUT body take
+// precedence over the URL query values.
+//
+// A body sent with "Content-Encoding: gzip" is decompressed before it is
+// parsed, and decompression errors are returned.
+func (dec *Decoder) DecodeRequest(r *http.Request, v any) error {
+	if err := decompressBody(r); err != nil {
+		return err
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	return dec.Decode(r.Form, v)
+}
+
+// LoadRequest parses the form of r and loads it into the struct pointed
+// to by v using the default Decoder.
+func LoadRequest(r *http.Request, v any) error {
+	return defaultDecoder.DecodeRequest(r, v)
+}
+
+// DecodeMultipart parses the multipart/form-data body of r, keeping up
+// to the size set with SetMaxMemory of its file parts in memory, and
+// loads the values of its text parts into the struct pointed to by v.
+//
+// A *multipart.FileHeader field receives the first file part of its key
+// and a []*multipart.FileHeader field all of them. Such fields are left
+// unchanged when the key has no file part. The other Decode methods
+// never load them.
+func (dec *Decoder) DecodeMultipart(r *http.Request, v any) error {
+	if err := r.ParseMultipartForm(dec.maxMemory); err != nil {
+		return err
+	}
+
+	d := dec.newDecodeState(context.Background(), r.MultipartForm.Value)
+	defer dec.freeDecodeState(d)
+	d.files = r.MultipartForm.File
+	return d.parse(v)
+}
+
+// LoadMultipart parses the multipart/form-data body of r and loads its
+// text values into the struct pointed to by v using the default Decoder.
+func LoadMultipart(r *http.Request, v any) error {
+	return defaultDecoder.DecodeMultipart(r, v)
+}
+
+// decompressBody replaces a gzip-encoded body of r that has not been
+// parsed yet by its decompressed content.
+func decompressBody(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody || r.PostForm != nil {
+		return nil
+	}
+	if !strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), "gzip") {
+		return nil
+	}
+
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = &gzipBody{Reader: zr, body: r.Body}
+	r.Header.Del("Content-Encoding")
+	return nil
+}
+
+// gzipBody is a decompressed request body that closes the original one.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	err := b.Reader.Close()
+	if cerr := b.body.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package form
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRequest(t *testing.T) {
+	req, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodPost,
+		"http://localhost?type=query&Status=query",
+		strings.NewReader("type=body"),
+	)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var obj testStatusObj
+	err = LoadRequest(req, &obj)
+	assert.NoError(t, err)
+
+	assert.Equal(t, testStatusObj{Status: "query", Type: "body"}, obj)
+}
+
+func TestLoadRequest_ParseError(t *testing.T) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost", nil)
+	assert.NoError(t, err)
+	req.URL.RawQuery = "type=%zz"
+
+	var obj testStatusObj
+	assert.Error(t, LoadRequest(req, &obj))
+}
+
+func TestLoadRequest_Gzip(t *testing.T) {
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	_, err := zw.Write([]byte("type=body"))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost", &body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	var obj testStatusObj
+	assert.NoError(t, LoadRequest(req, &obj))
+	assert.Equal(t, "body", obj.Type)
+
+	req, err = http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost", strings.NewReader("type=body"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Encoding", "gzip")
+	assert.Error(t, LoadRequest(req, &obj))
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, value := range fields {
+		assert.NoError(t, mw.WriteField(name, value))
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile(name, name+".txt")
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mw.Close())
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost?Status=query", &body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestLoadMultipart(t *testing.T) {
+	req := newMultipartRequest(t, map[string]string{"type": "body"}, map[string]string{"Status": "file"})
+
+	var obj testStatusObj
+	assert.NoError(t, LoadMultipart(req, &obj))
+	assert.Equal(t, testStatusObj{Type: "body"}, obj)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost", strings.NewReader("type=body"))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.Error(t, LoadMultipart(req, &obj))
+}
+
+type testUploadObj struct {
+	Title       string                  `request:"title"`
+	Avatar      *multipart.FileHeader   `request:"avatar"`
+	Attachments []*multipart.FileHeader `request:"attachments"`
+	Cover       *multipart.FileHeader   `request:"cover"`
+}
+
+func TestLoadMultipart_Files(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	assert.NoError(t, mw.WriteField("title", "report"))
+	for _, part := range []struct{ name, file string }{
+		{"avatar", "me.png"},
+		{"attachments", "a.txt"},
+		{"attachments", "b.txt"},
+	} {
+		fw, err := mw.CreateFormFile(part.name, part.file)
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(part.file))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, mw.Close())
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost", &body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var obj testUploadObj
+	assert.NoError(t, LoadMultipart(req, &obj))
+	assert.Equal(t, "report", obj.Title)
+	if assert.NotNil(t, obj.Avatar) {
+		assert.Equal(t, "me.png", obj.Avatar.Filename)
+	}
+	if assert.Len(t, obj.Attachments, 2) {
+		assert.Equal(t, "a.txt", obj.Attachments[0].Filename)
+		assert.Equal(t, "b.txt", obj.Attachments[1].Filename)
+	}
+	assert.Nil(t, obj.Cover)
+
+	obj = testUploadObj{}
+	assert.NoError(t, Load(map[string][]string{"title": {"x"}, "avatar": {"me.png"}}, &obj))
+	assert.Nil(t, obj.Avatar)
+}
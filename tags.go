@@ -0,0 +1,89 @@
+// Copyright 2023 Urvantsev Evgenii. All rights reserved.
+// Use of this source code is governed by a BSD3-style
+// license that can be found in the LICENSE file.
+
+package form
+
+import "strings"
+
+// knownOptions are the flags recognized in the options of a tag.
+var knownOptions = map[string]bool{
+	"required":  true,
+	"omitempty": true,
+	"squash":    true,
+	"kv":        true,
+	"presence":  true,
+}
+
+// tagOptions is the string following a comma in a struct field's tag,
+// or the empty string. It does not include the leading comma.
+type tagOptions string
+
+// parseTag splits a struct field's tag into its name and
+// comma-separated options.
+func parseTag(tag string) (string, tagOptions) {
+	name, opt, _ := strings.Cut(tag, ",")
+	return name, tagOptions(opt)
+}
+
+// Contains reports whether a comma-separated list of options
+// contains a particular optionName flag. optionName must be
+// surrounded by a string boundary or commas.
+func (o tagOptions) Contains(optionName string) bool {
+	if len(o) == 0 {
+		return false
+	}
+	s := string(o)
+	for s != "" {
+		var name string
+		name, s, _ = strings.Cut(s, ",")
+		if name == optionName {
+			return true
+		}
+	}
+	return false
+}
+
+// Values returns the values of the optionName=value settings in order,
+// splitting each value at "|". It returns nil if there are none.
+func (o tagOptions) Values(optionName string) []string {
+	var values []string
+	s := string(o)
+	for s != "" {
+		var opt string
+		opt, s, _ = strings.Cut(s, ",")
+		if value, ok := strings.CutPrefix(opt, optionName+"="); ok {
+			values = append(values, strings.Split(value, "|")...)
+		}
+	}
+	return values
+}
+
+// cutMessage splits the msg= setting from the other options and returns
+// them with its value. The setting takes the rest of the options, commas
+// included, so it must come last: "required,msg=Please, provide an email".
+func (o tagOptions) cutMessage() (tagOptions, string) {
+	s := string(o)
+	if msg, ok := strings.CutPrefix(s, "msg="); ok {
+		return "", msg
+	}
+	if before, msg, ok := strings.Cut(s, ",msg="); ok {
+		return tagOptions(before), msg
+	}
+	return o, ""
+}
+
+// Names returns the options that are neither known flags nor key=value
+// settings, in order. They are alternative form keys of the field.
+func (o tagOptions) Names() []string {
+	var names []string
+	s := string(o)
+	for s != "" {
+		var name string
+		name, s, _ = strings.Cut(s, ",")
+		if name != "" && !knownOptions[name] && !strings.Contains(name, "=") {
+			names = append(names, name)
+		}
+	}
+	return names
+}